@@ -0,0 +1,136 @@
+package cartridge
+
+import (
+	"log"
+	"math"
+)
+
+const (
+	ModBankMusic = iota
+)
+
+// song positions in resources/0.mod
+const (
+	musicTitle = 0
+	musicGame  = 2
+)
+
+// samples in resources/0.mod
+const (
+	sampleClaves      = 2
+	sampleMonsterBass = 6
+	sampleNice        = 7
+	samplePopSnare    = 9
+	sampleShaker      = 10
+	sampleStabs       = 11
+	sampleWoodblock   = 12
+	sampleBassDrum    = 13
+)
+
+var digSamples = []int{sampleWoodblock, sampleClaves, sampleShaker, samplePopSnare}
+
+const settingsFile = "settings.json"
+
+// Audio plays the music and sound effects through the mod bank, and keeps
+// the player's volume and mute settings.
+type Audio struct {
+	Volume float64
+	Muted  bool
+}
+
+var audio = &Audio{Volume: 0.8}
+
+// loadSettings restores the audio settings, keeping the defaults if there
+// aren't any saved yet.
+func (a *Audio) loadSettings() {
+	if !hasConfig(settingsFile) {
+		return
+	}
+	if err := readConfig(settingsFile, a); err != nil {
+		log.Println("settings:", err)
+	}
+}
+
+func (a *Audio) saveSettings() {
+	if savesInMemory {
+		return // a replay's (or the bot's) keys shouldn't change the real settings.
+	}
+	if err := writeConfig(settingsFile, a); err != nil {
+		log.Println("settings:", err)
+	}
+}
+
+func (a *Audio) volume() float64 {
+	if a.Muted {
+		return 0
+	}
+	return a.Volume
+}
+
+// PlayMusic loops the song from the given position.
+func (a *Audio) PlayMusic(position int) {
+	mod := console.ModBanksGet(ModBankMusic)
+	mod.SetVolume(a.volume())
+	mod.PlayFrom(position)
+}
+
+func (a *Audio) StopMusic() {
+	console.ModBanksGet(ModBankMusic).Stop()
+}
+
+func (a *Audio) Sfx(sample, note int) {
+	if a.volume() > 0 {
+		console.ModBanksGet(ModBankMusic).PlaySample(sample, note, a.volume())
+	}
+}
+
+// DigStroke gets higher pitched (and changes instrument) as the hole deepens.
+func (a *Audio) DigStroke(stroke int) {
+	a.Sfx(digSamples[stroke%len(digSamples)], stroke*2)
+}
+
+func (a *Audio) Thunk() {
+	a.Sfx(sampleBassDrum, 0)
+}
+
+func (a *Audio) Happy() {
+	a.Sfx(sampleNice, 12)
+}
+
+func (a *Audio) Angry() {
+	a.Sfx(sampleMonsterBass, 0)
+	a.Sfx(sampleStabs, -5)
+}
+
+func (a *Audio) ToggleMute() {
+	a.Muted = !a.Muted
+	a.apply()
+}
+
+func (a *Audio) ChangeVolume(delta float64) {
+	a.Volume = math.Round(math.Max(0, math.Min(1, a.Volume+delta))*10) / 10
+	a.Muted = false
+	a.apply()
+}
+
+func (a *Audio) apply() {
+	console.ModBanksGet(ModBankMusic).SetVolume(a.volume())
+	a.saveSettings()
+}
+
+// Update handles the M (mute) and -/+ (volume) keys.
+func (a *Audio) Update() {
+	if text != nil && text.plotInput {
+		return // those keys are for typing plots.
+	}
+	for _, c := range console.InputChars() {
+		switch c {
+		case 'm', 'M':
+			a.ToggleMute()
+		case '-', '_':
+			a.ChangeVolume(-0.1)
+		case '+', '=':
+			a.ChangeVolume(0.1)
+		}
+	}
+}
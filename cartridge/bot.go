@@ -0,0 +1,527 @@
+package cartridge
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+)
+
+// The bot plays the game by clicking and typing, the same as a player would,
+// and works out who's where from only what a player could see: the clues
+// visitors give and the traits drawn on the graves. Anything that stops it
+// doing what it meant to, e.g. a click that doesn't land, is kept as a
+// problem in its BotReport, as is a wrong plot for a visitor whose clues
+// only fit their own grave.
+
+// BotReport is how a bot run went.
+type BotReport struct {
+	Seed       int64
+	Level      int
+	Score      int
+	Reputation int
+	Found      int  // visitors given the right plot
+	Wrong      int  // visitors given the wrong plot
+	GaveUp     int  // visitors who ran out of patience, or were sent home at dusk
+	GameOver   bool // fired, rather than stopped or through a level pack
+	Frames     int
+	Problems   []string
+}
+
+type Bot struct {
+	levels int // stop once this many levels are done, 0 plays on until game over
+	report BotReport
+	done   bool
+
+	pos    image.Point // where the mouse is
+	wait   int         // frames to leave the game alone for
+	check  func() string
+	typing []rune
+	typed  func() string // the check once typing's done
+
+	visitors  *Visitors
+	opened    map[*Grave]bool // graves seen open today, so the open traits are known
+	submitted map[*Visitor]bool
+	puzzled   map[*Visitor]bool // nothing fits their clues
+	allDone   int               // frame every visitor was done, 0 if they aren't
+}
+
+// botConsole is fed the bot's input in place of the console's own.
+type botConsole struct {
+	inputConsole
+	bot *Bot
+}
+
+func (c *botConsole) nextFrame() {
+	c.frame = c.bot.nextFrame()
+}
+
+// PlayBot hands the mouse and keyboard over to the bot, which plays the given
+// number of levels (or on until game over if it's 0). Saves are kept in
+// memory. Call it after SetSeed and UseConsole, and before Start.
+func PlayBot(levels int) {
+	savesInMemory = true
+	memorySave = nil
+	bot := &Bot{levels: levels, report: BotReport{Seed: seed}}
+	console = &botConsole{inputConsole: inputConsole{Console: console}, bot: bot}
+}
+
+func runningBot() *Bot {
+	if c, ok := console.(*botConsole); ok {
+		return c.bot
+	}
+	return nil
+}
+
+// BotFinished reports whether the bot has played all it was asked to.
+func BotFinished() bool {
+	if b := runningBot(); b != nil {
+		return b.done
+	}
+	return false
+}
+
+// BotResult is the report for the bot's run so far.
+func BotResult() BotReport {
+	if b := runningBot(); b != nil {
+		return b.report
+	}
+	return BotReport{}
+}
+
+// botFrameLimit is how long the bot gets to play a level before it counts as
+// stuck, a day plus the night in between.
+const botFrameLimit = dayLengthFrames + 30*framesPerSecond
+
+func (b *Bot) problem(format string, args ...interface{}) {
+	b.report.Problems = append(b.report.Problems, fmt.Sprintf("level %d frame %d: ", lvlNum, b.report.Frames)+fmt.Sprintf(format, args...))
+}
+
+func (b *Bot) finish() {
+	b.tally()
+	b.done = true
+	b.report.Level = lvlNum
+	b.report.Score = score
+	b.report.Reputation = reputation
+}
+
+func (b *Bot) idle() ReplayFrame {
+	return ReplayFrame{Pos: b.pos}
+}
+
+func (b *Bot) click(p image.Point) ReplayFrame {
+	b.pos = p
+	return ReplayFrame{Pos: p, Pressed: true}
+}
+
+func (b *Bot) nextFrame() ReplayFrame {
+	if b.done {
+		return b.idle()
+	}
+	b.report.Frames++
+	if b.report.Frames > botFrameLimit*(b.levels+1) && b.levels > 0 {
+		b.problem("still on level %d", lvlNum)
+		b.finish()
+		return b.idle()
+	}
+
+	if b.wait > 0 {
+		b.wait--
+		return b.idle()
+	}
+	if b.check != nil {
+		if msg := b.check(); msg != "" {
+			b.problem("%s", msg)
+		}
+		b.check = nil
+	}
+	if len(b.typing) > 0 {
+		c := b.typing[0]
+		b.typing = b.typing[1:]
+		if len(b.typing) == 0 {
+			// Enter is seen by the text, then the player acts on it a
+			// frame later.
+			b.wait = 1
+			b.check, b.typed = b.typed, nil
+		}
+		return ReplayFrame{Pos: b.pos, Chars: string(c)}
+	}
+
+	switch mode {
+	case MODE_TITLE_SCREEN:
+		b.wait = 1
+		return b.click(image.Point{X: 17 * 6, Y: 23*8 + 4}) // New Game
+	case MODE_GAME:
+		return b.play()
+	case MODE_GAME_OVER:
+		b.report.GameOver = reputation <= 0
+		b.finish()
+	}
+	return b.idle()
+}
+
+// tally counts how today's visitors got on.
+func (b *Bot) tally() {
+	if b.visitors == nil {
+		return
+	}
+	for _, v := range b.visitors.Visitors {
+		switch {
+		case v.state == visitorHappy:
+			b.report.Found++
+		case b.submitted[v]:
+			b.report.Wrong++
+		case v.finished():
+			b.report.GaveUp++
+		}
+	}
+	b.visitors = nil
+}
+
+// newDay forgets what was learned about yesterday's graveyard.
+func (b *Bot) newDay() {
+	b.tally()
+	if b.levels > 0 && lvlNum > b.levels {
+		b.finish()
+		return
+	}
+	b.visitors = visitors
+	b.opened = map[*Grave]bool{}
+	b.submitted = map[*Visitor]bool{}
+	b.puzzled = map[*Visitor]bool{}
+	b.allDone = 0
+}
+
+func (b *Bot) play() ReplayFrame {
+	if b.visitors != visitors {
+		b.newDay()
+		if b.done {
+			return b.idle()
+		}
+	}
+	for _, g := range graveyard.graves {
+		if g.clickcounter >= g.DigDepth {
+			b.opened[g] = true
+		}
+	}
+
+	if b.allVisitorsDone() {
+		if b.allDone == 0 {
+			b.allDone = b.report.Frames
+		} else if b.report.Frames-b.allDone > 10*framesPerSecond {
+			b.problem("no next day after every visitor was done")
+			b.allDone = b.report.Frames
+		}
+		return b.idle()
+	}
+
+	if text.notebookOpen {
+		b.typing = []rune{'n'}
+		return b.idle()
+	}
+	if player.busy() || !camera.still() {
+		return b.idle()
+	}
+
+	for _, v := range visitors.Visitors {
+		if !v.talkedTo && !v.finished() && v.Hitbox != (Hitbox{}) {
+			return b.talkTo(v)
+		}
+	}
+
+	unsolved := []*Visitor{}
+	for _, v := range visitors.Visitors {
+		if v.talkedTo && !v.finished() && !b.submitted[v] && !b.puzzled[v] {
+			unsolved = append(unsolved, v)
+		}
+	}
+	for _, v := range unsolved {
+		candidates := b.candidates(v)
+		if len(candidates) == 0 {
+			b.problem("nothing matches visitor %d's clues %q", v.number(), v.Grave.Likes)
+			b.puzzled[v] = true
+			continue
+		}
+		if !player.flagDoneSomeDigging {
+			continue // they won't take a plot yet.
+		}
+		if g := b.solved(v, candidates); g != nil {
+			return b.submit(v, g)
+		}
+	}
+
+	if g := b.toRefill(unsolved); g != nil && player.stamina >= refillStaminaCost {
+		return b.dig(g)
+	}
+	if g := b.toDig(unsolved); g != nil {
+		return b.dig(g)
+	}
+	// nothing left to learn today. Guessing would only hide a plot that
+	// should have been found, so whoever's left waits it out.
+	return b.idle()
+}
+
+func (b *Bot) allVisitorsDone() bool {
+	for _, v := range visitors.Visitors {
+		if !v.finished() {
+			return false
+		}
+	}
+	return true
+}
+
+// finished is whether the visitor's done for the day, which (unlike done)
+// stays true once nextLevel is on its way.
+func (v *Visitor) finished() bool {
+	return v.done || v.state == visitorHappy || v.state == visitorAngry || v.state == visitorGone
+}
+
+func (v *Visitor) number() int {
+	return (v.SpriteId - SpriteVisitor1) + 1
+}
+
+// busy is whether the player is still walking or digging.
+func (p *Player) busy() bool {
+	return len(p.path) > 0 || p.digTarget != nil || p.digAction.Active()
+}
+
+// still is whether the camera will stay put this frame, so a click lands
+// where it was aimed.
+func (c *Camera) still() bool {
+	goalX, goalY := c.clamp(
+		c.TargetX-float64(fullScreenRect.Dx()/2),
+		c.TargetY-float64(fullScreenRect.Dy()/2),
+	)
+	return !c.moving && !c.shake.Active() && math.Abs(goalX-c.X) <= c.DeadZone && math.Abs(goalY-c.Y) <= c.DeadZone
+}
+
+// seen reports whether the bot knows what the grave shows for the category.
+// Every grave starts the day closed, so those traits are always known.
+func (b *Bot) seen(g *Grave, cat int) bool {
+	return TraitCategories[cat].Closed || (TraitCategories[cat].Open && b.opened[g])
+}
+
+// candidates are the graves the visitor's clues could still be describing.
+func (b *Bot) candidates(v *Visitor) []*Grave {
+	candidates := []*Grave{}
+	for _, g := range graveyard.graves {
+		fits := true
+		for _, like := range v.Grave.Likes {
+			if b.seen(g, likeCategory(like)) && !g.HasTrait(like) {
+				fits = false
+			}
+		}
+		if fits {
+			candidates = append(candidates, g)
+		}
+	}
+	return candidates
+}
+
+// solved returns the visitor's grave if it can only be the one, either
+// because it's the last candidate or because every clue has been seen on it
+// (the clues only fit one grave in the whole graveyard, unless the
+// difficulty left them ambiguous, when it's as good as any).
+func (b *Bot) solved(v *Visitor, candidates []*Grave) *Grave {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	for _, g := range candidates {
+		all := true
+		for _, like := range v.Grave.Likes {
+			all = all && b.seen(g, likeCategory(like))
+		}
+		if all {
+			return g
+		}
+	}
+	return nil
+}
+
+// toRefill is an opened grave nobody still waiting could be looking for,
+// which would otherwise cost reputation.
+func (b *Bot) toRefill(unsolved []*Visitor) *Grave {
+	for _, g := range graveyard.graves {
+		if g.clickcounter < g.DigDepth {
+			continue
+		}
+		wanted := false
+		for _, v := range visitors.Visitors {
+			if v.state == visitorHappy && v.Grave == g {
+				wanted = true // they found them, so we know.
+			}
+		}
+		for _, v := range unsolved {
+			for _, c := range b.candidates(v) {
+				wanted = wanted || c == g
+			}
+		}
+		if !wanted {
+			return g
+		}
+	}
+	return nil
+}
+
+// toDig picks the next grave to dig, finishing off graves already started
+// first, then whichever could be the grave of the most visitors still to
+// find, so each dig tells the most. Ties go to whoever has the fewest
+// candidates left.
+func (b *Bot) toDig(unsolved []*Visitor) *Grave {
+	sort.SliceStable(unsolved, func(i, j int) bool {
+		return len(b.candidates(unsolved[i])) < len(b.candidates(unsolved[j]))
+	})
+	wanted := map[*Grave]int{}
+	for _, v := range unsolved {
+		for _, g := range b.candidates(v) {
+			wanted[g]++
+		}
+	}
+	var best *Grave
+	for _, v := range unsolved {
+		for _, g := range b.candidates(v) {
+			if b.opened[g] || player.stamina < g.DigDepth-g.clickcounter {
+				continue
+			}
+			if best == nil ||
+				g.clickcounter > 0 && best.clickcounter == 0 ||
+				(g.clickcounter > 0) == (best.clickcounter > 0) && wanted[g] > wanted[best] {
+				best = g
+			}
+		}
+	}
+	if best != nil {
+		return best
+	}
+	if !player.flagDoneSomeDigging && len(unsolved) > 0 {
+		// a stroke anywhere, as plots aren't taken until some digging's done.
+		for _, g := range graveyard.graves {
+			if g.clickcounter < g.DigDepth-1 && player.stamina >= digStaminaCost {
+				return g
+			}
+		}
+	}
+	return nil
+}
+
+// uiAt reports whether a click at p would land on the text layer rather than
+// the graveyard.
+func (b *Bot) uiAt(p image.Point) bool {
+	return text.notebookButton().IsHitNoCameraOffset(p) ||
+		(text.plotInput && text.Hitbox.IsHitNoCameraOffset(p))
+}
+
+// screenPoint finds somewhere on screen within the hitbox (in graveyard
+// pixels) that a click would land on what hits says, as near its middle as
+// possible.
+func (b *Bot) screenPoint(hb Hitbox, hits func(p image.Point) bool) (image.Point, bool) {
+	cp := camera.GetAsPoint()
+	centre := hb.Min.Add(hb.Max).Div(2).Sub(cp)
+	best, found := image.Point{}, false
+	bestDist := 0
+	for y := hb.Min.Y + 1; y < hb.Max.Y; y += 2 {
+		for x := hb.Min.X + 1; x < hb.Max.X; x += 2 {
+			p := image.Point{X: x, Y: y}.Sub(cp)
+			if !p.In(fullScreenRect) || b.uiAt(p) || !hits(p) {
+				continue
+			}
+			d := p.Sub(centre)
+			dist := d.X*d.X + d.Y*d.Y
+			if !found || dist < bestDist {
+				best, bestDist, found = p, dist, true
+			}
+		}
+	}
+	return best, found
+}
+
+// walkTowards clicks the bit of open ground on screen nearest the hitbox, so
+// the camera follows the player over to it.
+func (b *Bot) walkTowards(hb Hitbox) ReplayFrame {
+	cp := camera.GetAsPoint()
+	target := hb.Min.Add(hb.Max).Div(2).Sub(cp)
+	best, found := image.Point{}, false
+	bestDist := 0
+	for y := 4; y < fullScreenRect.Dy(); y += 8 {
+		for x := 4; x < fullScreenRect.Dx(); x += 8 {
+			p := image.Point{X: x, Y: y}
+			if b.uiAt(p) || graveyard.GetClickedGrave(p) != nil || visitors.GetClickedVisitor(p) != nil {
+				continue
+			}
+			d := p.Sub(target)
+			dist := d.X*d.X + d.Y*d.Y
+			if !found || dist < bestDist {
+				best, bestDist, found = p, dist, true
+			}
+		}
+	}
+	if !found {
+		b.problem("nowhere to walk to")
+		return b.idle()
+	}
+	b.wait = 1
+	return b.click(best)
+}
+
+func (b *Bot) talkTo(v *Visitor) ReplayFrame {
+	p, found := b.screenPoint(v.Hitbox, func(p image.Point) bool {
+		return graveyard.GetClickedGrave(p) == nil && visitors.GetClickedVisitor(p) == v
+	})
+	if !found {
+		return b.walkTowards(v.Hitbox)
+	}
+	b.check = func() string {
+		if visitors.currentVisitor != v || !v.talkedTo {
+			return fmt.Sprintf("clicked visitor %d at %v but didn't talk to them", v.number(), p)
+		}
+		return ""
+	}
+	return b.click(p)
+}
+
+func (b *Bot) dig(g *Grave) ReplayFrame {
+	p, found := b.screenPoint(g.Hitbox, func(p image.Point) bool {
+		return graveyard.GetClickedGrave(p) == g
+	})
+	if !found {
+		return b.walkTowards(g.Hitbox)
+	}
+	b.check = func() string {
+		if player.digTarget != g && !player.digAction.Active() {
+			return fmt.Sprintf("clicked plot %s at %v but didn't go to dig it", g.Plot(), p)
+		}
+		return ""
+	}
+	return b.click(p)
+}
+
+// submit talks to the visitor again, to bring up the plot input, and types
+// in the plot.
+func (b *Bot) submit(v *Visitor, g *Grave) ReplayFrame {
+	frame := b.talkTo(v)
+	if !frame.Pressed || b.check == nil {
+		return frame // still getting over to them.
+	}
+	talked := b.check
+	b.check = func() string {
+		if msg := talked(); msg != "" {
+			return msg
+		}
+		if !text.plotInput {
+			return fmt.Sprintf("talked to visitor %d but got no plot input", v.number())
+		}
+		return ""
+	}
+	b.typing = append([]rune(g.Plot()), '\n')
+	b.typed = func() string {
+		if !v.finished() {
+			return fmt.Sprintf("typed plot %s for visitor %d but they didn't take it", g.Plot(), v.number())
+		}
+		if matches := graveyard.MatchingPlots(v.Grave.Likes); v.state != visitorHappy && len(matches) == 1 {
+			return fmt.Sprintf("gave visitor %d plot %s but only %s fits their clues", v.number(), g.Plot(), matches[0])
+		}
+		return ""
+	}
+	b.submitted[v] = true
+	return frame
+}
@@ -0,0 +1,41 @@
+package cartridge
+
+import (
+	"image"
+	"testing"
+)
+
+func TestCamera(t *testing.T) {
+	graveyard = &Graveyard{}
+	graveyard.setSize(4, maxCols)
+	bottomRight := graveyard.Size().Sub(fullScreenRect.Size())
+	centre := fullScreenRect.Size().Div(2)
+
+	for _, test := range []struct {
+		name   string
+		target image.Point
+		want   image.Point
+	}{
+		{"eases there", image.Point{X: 300, Y: 400}, image.Point{X: 300, Y: 400}.Sub(centre)},
+		{"clamped to the top left", image.Point{X: -500, Y: -500}, image.Point{}},
+		{"clamped to the bottom right", image.Point{X: 5000, Y: 5000}, bottomRight},
+		{"not past the dead zone", centre.Add(image.Point{X: 8, Y: -8}), image.Point{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewCamera()
+			c.LookAt(float64(test.target.X), float64(test.target.Y))
+			c.Update()
+			if test.want != (image.Point{}) {
+				if pos := c.GetAsPoint(); pos == test.want || pos == (image.Point{}) {
+					t.Fatalf("jumped to %v on the first frame, want part way to %v", pos, test.want)
+				}
+			}
+			for i := 0; i < 200; i++ {
+				c.Update()
+			}
+			if pos := c.GetAsPoint(); pos != test.want {
+				t.Fatalf("settled at %v, want %v", pos, test.want)
+			}
+		})
+	}
+}
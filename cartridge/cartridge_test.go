@@ -0,0 +1,878 @@
+package cartridge_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TheMightyGit/losttheplot/cartridge"
+	"github.com/TheMightyGit/losttheplot/cartridge/headless"
+)
+
+// newGame boots the cartridge on a headless console, saving to a temporary
+// directory, and starts a new game.
+func newGame(t *testing.T) *headless.Console {
+	t.Helper()
+	return newGameSeed(t, 1)
+}
+
+func newGameSeed(t *testing.T, seed int64) *headless.Console {
+	t.Helper()
+	return newGameIn(t, t.TempDir(), seed)
+}
+
+// newGameIn is newGame keeping saves and settings in dir.
+func newGameIn(t *testing.T, dir string, seed int64) *headless.Console {
+	t.Helper()
+	cartridge.SetConfigDir(dir)
+	cartridge.SetSeed(seed)
+	c := headless.New()
+	cartridge.UseConsole(c)
+	cartridge.Start()
+	c.Run(2, cartridge.Update)
+	c.Click(newGameButton)
+	c.Run(2, cartridge.Update)
+	arrive(c)
+	return c
+}
+
+// arrive updates until the visitors have come in through the gate.
+func arrive(c *headless.Console) {
+	for i := 0; i < 60*60 && !cartridge.VisitorsArrived(); i++ {
+		c.Run(1, cartridge.Update)
+	}
+}
+
+var (
+	newGameButton  = image.Point{X: 17 * 6, Y: 23*8 + 4}
+	continueButton = image.Point{X: 37 * 6, Y: 23*8 + 4}
+)
+
+// continueGame boots the cartridge again and picks up the saved game.
+func continueGame(t *testing.T) *headless.Console {
+	t.Helper()
+	c := headless.New()
+	cartridge.UseConsole(c)
+	cartridge.Start()
+	c.Run(2, cartridge.Update)
+	runUntil(t, c, 1, " Continue")
+	c.Click(continueButton)
+	c.Run(2, cartridge.Update)
+	return c
+}
+
+func screen(c *headless.Console) string {
+	return c.MapBanks[cartridge.MapBankText].Areas[0].Text()
+}
+
+// runUntil updates until the screen shows want, failing after frames.
+func runUntil(t *testing.T, c *headless.Console, frames int, want string) {
+	t.Helper()
+	for i := 0; i < frames; i++ {
+		if strings.Contains(screen(c), want) {
+			return
+		}
+		c.Run(1, cartridge.Update)
+	}
+	t.Fatalf("no %q after %d frames:\n%s", want, frames, screen(c))
+}
+
+// clickVisitor clicks the middle of the first visitor's sprite.
+func clickVisitor(t *testing.T, c *headless.Console) {
+	t.Helper()
+	clickVisitorN(t, c, 0)
+}
+
+func clickVisitorN(t *testing.T, c *headless.Console, n int) {
+	t.Helper()
+	s := c.Sprites[cartridge.SpriteVisitor1+n]
+	if s == nil || !s.Visible {
+		t.Fatalf("no visitor %d to click on", n+1)
+	}
+	c.Click(s.Pos.Min.Add(image.Point{X: 16, Y: 32}))
+	c.Run(1, cartridge.Update)
+}
+
+// clickPlot clicks the first grave in the row, once the camera's settled,
+// and waits for the gravedigger to walk over and dig it.
+func clickPlot(c *headless.Console, row int) {
+	clickGrave(c, row, 0)
+}
+
+// clickGrave is clickPlot for any column.
+func clickGrave(c *headless.Console, row, col int) {
+	settle(c)
+	c.Click(image.Point{X: (8+col*11)*8 + 16, Y: (16+row*16)*8 + 40}.Sub(cartridge.CameraPos()))
+	c.Run(1, cartridge.Update)
+	for i := 0; i < 60*60 && cartridge.Digging(); i++ {
+		c.Run(1, cartridge.Update)
+	}
+	// off the grave, so its tooltip doesn't cover what's said.
+	c.Queue(headless.Input{Pos: image.Point{X: 2, Y: 100}})
+	c.Run(1, cartridge.Update)
+}
+
+// choosePlot picks the plot's row letter then its column number in the plot
+// picker.
+func choosePlot(c *headless.Console, plot string) {
+	row, col := int(plot[0]-'A'), int(plot[1]-'1')
+	c.Click(image.Point{X: 6*11 + 13 + (3+row*3)*6 + 3, Y: 22*8 + 4})
+	c.Run(1, cartridge.Update)
+	c.Click(image.Point{X: 6*11 + 13 + (3+col*3)*6 + 3, Y: 23*8 + 4})
+	c.Run(1, cartridge.Update)
+}
+
+// settle updates until the camera stops moving.
+func settle(c *headless.Console) {
+	for i := 0; i < 10*60; i++ {
+		pos := cartridge.CameraPos()
+		c.Run(1, cartridge.Update)
+		if cartridge.CameraPos() == pos {
+			return
+		}
+	}
+}
+
+// wrongPlot is a plot on the same row as plot, but not it.
+func wrongPlot(plot string) string {
+	if plot[1] == '1' {
+		return plot[:1] + "2"
+	}
+	return plot[:1] + "1"
+}
+
+func TestNewGame(t *testing.T) {
+	c := newGame(t)
+	runUntil(t, c, 1, "Level 1 Seed 1")
+	runUntil(t, c, 1, "I must talk to the visitors")
+	if s := c.Sprites[cartridge.SpritePlayer]; s == nil || !s.Visible {
+		t.Fatal("no gravedigger")
+	}
+}
+
+func TestTalkToVisitor(t *testing.T) {
+	c := newGame(t)
+	clickVisitor(t, c)
+	runUntil(t, c, 1, "Where is my...")
+	runUntil(t, c, 1, "Visitor 1")
+}
+
+func TestSpeechBoxFitsClues(t *testing.T) {
+	for seed := int64(1); seed < 100; seed++ {
+		c := newGameSeed(t, seed)
+		for i, clues := range cartridge.VisitorClues() {
+			if len(clues) < 4 {
+				continue
+			}
+			clickVisitorN(t, c, i)
+			text := c.MapBanks[cartridge.MapBankText].Areas[0]
+			lines := strings.Split(text.Text(), "\n")
+			if !strings.Contains(lines[7], clues[3]) {
+				t.Fatalf("last clue not on row 7:\n%s", text.Text())
+			}
+			// the bottom left corner of the box is below the last clue.
+			if cell := text.Cell(image.Point{X: 0, Y: 8}); cell.X != 17 || cell.Y != 2 {
+				t.Fatalf("box bottom is tile %d,%d, want 17,2", cell.X, cell.Y)
+			}
+			return
+		}
+	}
+	t.Fatal("no visitor with four clues to try")
+}
+
+func TestScore(t *testing.T) {
+	c := newGame(t)
+	runUntil(t, c, 1, "Score     0")
+	runUntil(t, c, 1, " Rep #####---")
+
+	clickVisitor(t, c)
+	clickPlot(c, 0) // there's no picking a plot before some digging.
+	clickVisitor(t, c)
+	runUntil(t, c, 1, "They're in plot...")
+	plot := cartridge.VisitorPlots()[0]
+	choosePlot(c, plot)
+	runUntil(t, c, 2, "They're 100% in "+plot)
+	runUntil(t, c, 1, "Score   100")
+	runUntil(t, c, 1, " Rep ######--")
+}
+
+func TestGameOver(t *testing.T) {
+	c := newGame(t)
+	clickVisitor(t, c)
+	clickPlot(c, 0)
+	for i, plot := range cartridge.VisitorPlots()[:3] {
+		clickVisitorN(t, c, i)
+		choosePlot(c, wrongPlot(plot))
+		runUntil(t, c, 2, "They're 100% in "+wrongPlot(plot))
+	}
+	runUntil(t, c, 2, "You're fired!")
+	runUntil(t, c, 1, "Score 0, on day 1.")
+}
+
+func TestTypePlot(t *testing.T) {
+	c := newGame(t)
+	clickVisitor(t, c)
+	clickPlot(c, 0)
+	clickVisitor(t, c)
+	plot := cartridge.VisitorPlots()[0]
+	wrong := wrongPlot(plot)
+
+	c.Type(wrong)
+	c.RunScript(cartridge.Update)
+	runUntil(t, c, 1, "They're in plot... "+wrong)
+	c.Type("\b")
+	c.RunScript(cartridge.Update)
+	runUntil(t, c, 1, "They're in plot... "+plot[:1]+"\n")
+	c.Type(strings.ToLower(plot[1:]) + "\n")
+	c.RunScript(cartridge.Update)
+	runUntil(t, c, 2, "They're 100% in "+plot)
+	runUntil(t, c, 1, "Score   100")
+}
+
+func TestClosingTime(t *testing.T) {
+	t.Cleanup(func() { cartridge.SetDifficulty("Normal") })
+	for _, tc := range []struct{ difficulty, rep string }{
+		// a single black mark (from 70), not one for each of them.
+		{"Easy", " Rep ######--"},
+		{"Normal", " Rep #####---"},
+		{"Hard", " Rep ####----"},
+	} {
+		t.Run(tc.difficulty, func(t *testing.T) {
+			dir := t.TempDir()
+			c := newGameIn(t, dir, 1)
+			finishDay(t, c)
+
+			// wind the saved day on to just before dusk, with everyone waiting.
+			path := filepath.Join(dir, "save.json")
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			save := map[string]interface{}{}
+			if err := json.Unmarshal(data, &save); err != nil {
+				t.Fatal(err)
+			}
+			save["Clock"] = 5*60*60 - 10
+			save["Reputation"] = 70
+			save["Difficulty"] = tc.difficulty
+			waiting := save["Visitors"].([]interface{})
+			if len(waiting) < 2 {
+				t.Fatalf("only %d visitors to wait", len(waiting))
+			}
+			for _, v := range waiting {
+				v.(map[string]interface{})["State"] = 2 // waiting
+				v.(map[string]interface{})["Patience"] = 60 * 60
+			}
+			if data, err = json.Marshal(save); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			c = continueGame(t)
+			runUntil(t, c, 20, "Closing Time")
+			runUntil(t, c, 1, tc.rep)
+		})
+	}
+}
+
+// finishDay finds every visitor's plot, then waits for the next day.
+func finishDay(t *testing.T, c *headless.Console) {
+	t.Helper()
+	clickVisitor(t, c)
+	clickPlot(c, 0)
+	for i, plot := range cartridge.VisitorPlots() {
+		clickVisitorN(t, c, i)
+		choosePlot(c, plot)
+		runUntil(t, c, 2, "They're 100% in "+plot)
+	}
+	// the next day starts, and is saved, a few seconds after the last visitor.
+	runUntil(t, c, 7*60, "<The next day...>")
+}
+
+func TestContinue(t *testing.T) {
+	c := newGame(t)
+	finishDay(t, c)
+	runUntil(t, c, 1, "Level 2 Seed 1")
+
+	c = continueGame(t)
+	runUntil(t, c, 1, "<Back to work...>")
+	runUntil(t, c, 1, "Level 2 Seed 1")
+	runUntil(t, c, 1, "Score   500")
+}
+
+func TestContinueLevelPack(t *testing.T) {
+	t.Cleanup(cartridge.PlayMadeUpLevels)
+	dir := t.TempDir()
+	pack := filepath.Join(dir, "pack")
+	if err := os.Mkdir(pack, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"First", "Second"} {
+		level := `{
+			"name": "` + name + `",
+			"rows": 1,
+			"columns": 1,
+			"graves": [{"plot": "A1", "relation": "great aunt", "likes": ["They really liked wood."], "digDepth": 1}],
+			"visitors": [{"target": "A1"}]
+		}`
+		if err := os.WriteFile(filepath.Join(pack, fmt.Sprintf("0%d.json", i+1)), []byte(level), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cartridge.PlayLevelPack(pack); err != nil {
+		t.Fatal(err)
+	}
+	c := newGameIn(t, dir, 1)
+	finishDay(t, c)
+	runUntil(t, c, 1, "Level 2: Second")
+
+	// started again without the pack, or even its files, the save still
+	// has it.
+	if err := os.RemoveAll(pack); err != nil {
+		t.Fatal(err)
+	}
+	cartridge.PlayMadeUpLevels()
+	c = continueGame(t)
+	runUntil(t, c, 1, "Level 2: Second")
+	clickVisitor(t, c)
+	clickPlot(c, 0)
+	clickVisitor(t, c)
+	choosePlot(c, "A1")
+	runUntil(t, c, 2, "They're 100% in A1")
+	runUntil(t, c, 7*60, "Every day of the level pack is done")
+}
+
+func TestSound(t *testing.T) {
+	dir := t.TempDir()
+	c := newGameIn(t, dir, 1)
+	music := c.ModBanks[cartridge.ModBankMusic]
+	if !music.Playing || music.Volume != 0.8 {
+		t.Fatalf("music playing %v at %v, want playing at 0.8", music.Playing, music.Volume)
+	}
+	clickVisitor(t, c)
+	clickPlot(c, 0)
+	if len(music.Samples) == 0 {
+		t.Fatal("digging made no sound")
+	}
+
+	c.Type("m")
+	c.RunScript(cartridge.Update)
+	if music.Volume != 0 {
+		t.Fatalf("muted music at %v", music.Volume)
+	}
+	c.Type("+")
+	c.RunScript(cartridge.Update)
+	if music.Volume != 0.9 {
+		t.Fatalf("turning it up made it %v, want 0.9", music.Volume)
+	}
+
+	// the volume's kept for next time.
+	data, err := os.ReadFile(filepath.Join(dir, "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := struct{ Volume float64 }{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	if settings.Volume != 0.9 {
+		t.Fatalf("saved volume %v, want 0.9", settings.Volume)
+	}
+}
+
+func TestRowsPastZ(t *testing.T) {
+	c := newGame(t)
+	cartridge.StartLevel(28)
+	arrive(c)
+	clickVisitor(t, c)
+	for i := 0; i < 10 && !strings.Contains(screen(c), "That's got you"); i++ {
+		clickPlot(c, 27)
+	}
+	runUntil(t, c, 1, "That's got you, AB1")
+
+	clickVisitor(t, c)
+	c.Type("AB1")
+	c.RunScript(cartridge.Update)
+	runUntil(t, c, 1, "They're in plot... AB1")
+}
+
+func TestRowPickerPages(t *testing.T) {
+	c := newGame(t)
+	cartridge.StartLevel(100)
+	arrive(c)
+	clickVisitor(t, c)
+	clickPlot(c, 0)
+	clickVisitor(t, c)
+	runUntil(t, c, 1, "They're in plot...")
+
+	// 11 two letter rows fit on a page, so the last row, CV, is on its
+	// own on the tenth page.
+	nextPage := image.Point{X: 51*6 + 3, Y: 22*8 + 4}
+	for i := 0; i < 9; i++ {
+		c.Click(nextPage)
+		c.Run(1, cartridge.Update)
+	}
+	runUntil(t, c, 1, "< CV ")
+	c.Click(image.Point{X: 16*6 + 3, Y: 22*8 + 4})
+	c.Run(1, cartridge.Update)
+	runUntil(t, c, 2, "They're in plot... CV")
+	c.Click(image.Point{X: 16*6 + 3, Y: 23*8 + 4})
+	c.Run(1, cartridge.Update)
+	runUntil(t, c, 2, "They're 100% in CV1")
+}
+
+func TestSpadeSwing(t *testing.T) {
+	c := newGame(t)
+	clickVisitor(t, c)
+	settle(c)
+	c.Click(image.Point{X: 8*8 + 16, Y: 16*8 + 40}.Sub(cartridge.CameraPos()))
+	for i := 0; i < 10*60; i++ {
+		c.Run(1, cartridge.Update)
+		if c.Sprites[cartridge.SpritePlayer].Viewport.Y == 64 {
+			return
+		}
+	}
+	t.Fatal("the gravedigger never swung the spade")
+}
+
+func TestNoWayToGrave(t *testing.T) {
+	c := newGame(t)
+	clickVisitor(t, c)
+	cartridge.WallOff()
+	clickPlot(c, 0)
+	runUntil(t, c, 1, "I can't find a way over to A1!")
+	c.Run(60, cartridge.Update)
+	runUntil(t, c, 1, "I can't find a way over to A1!")
+}
+
+func TestNotebook(t *testing.T) {
+	c := newGame(t)
+	c.Type("n")
+	c.RunScript(cartridge.Update)
+	runUntil(t, c, 1, "Case Notebook")
+	runUntil(t, c, 1, "Visitor 1")
+	runUntil(t, c, 1, "not met yet")
+	c.Type("n")
+	c.RunScript(cartridge.Update)
+
+	clickVisitor(t, c)
+	runUntil(t, c, 1, "Where is my...")
+	clues := cartridge.VisitorClues()[0]
+	c.Type("n")
+	c.RunScript(cartridge.Update)
+	runUntil(t, c, 1, "Visitor 1: ")
+	like := strings.TrimSuffix(strings.TrimPrefix(clues[0], "They "), ".")
+	runUntil(t, c, 1, "   "+like)
+
+	c.Click(image.Point{X: 31 * 6, Y: 4})
+	c.Run(1, cartridge.Update)
+	runUntil(t, c, 1, "Nothing dug up yet.")
+
+	// closing it puts back what the visitor was saying.
+	c.Type("n")
+	c.RunScript(cartridge.Update)
+	runUntil(t, c, 1, "Where is my...")
+	if strings.Contains(screen(c), "Case Notebook") {
+		t.Fatalf("notebook still open:\n%s", screen(c))
+	}
+}
+
+func TestGraveTooltip(t *testing.T) {
+	c := newGame(t)
+	clickVisitor(t, c)
+	openPlot(t, c, "A1")
+
+	settle(c)
+	grave := image.Point{X: 8*8 + 16, Y: 16*8 + 40}.Sub(cartridge.CameraPos())
+	c.Queue(headless.Input{Pos: grave})
+	c.Run(1, cartridge.Update)
+	for _, part := range []string{"Headstone: ", "Body: ", "Wore: ", "Marker: "} {
+		runUntil(t, c, 1, part)
+	}
+
+	// what it covered comes back once the pointer's moved off.
+	c.Queue(headless.Input{Pos: image.Point{X: 2, Y: 100}})
+	c.Run(1, cartridge.Update)
+	runUntil(t, c, 1, "That's got you, A1")
+	if strings.Contains(screen(c), "Headstone: ") {
+		t.Fatalf("tooltip left behind:\n%s", screen(c))
+	}
+}
+
+// unwantedPlot is a plot in the first row no visitor's looking for.
+func unwantedPlot() string {
+	wanted := map[string]bool{}
+	for _, plot := range cartridge.VisitorPlots() {
+		wanted[plot] = true
+	}
+	for col := '1'; ; col++ {
+		if plot := "A" + string(col); !wanted[plot] {
+			return plot
+		}
+	}
+}
+
+// openPlot digs the plot (e.g. "B3") until it's open.
+func openPlot(t *testing.T, c *headless.Console, plot string) {
+	t.Helper()
+	for i := 0; i < 10 && !strings.Contains(screen(c), "That's got you, "+plot); i++ {
+		clickGrave(c, int(plot[0]-'A'), int(plot[1]-'1'))
+	}
+	runUntil(t, c, 1, "That's got you, "+plot)
+}
+
+func TestRefillOnlyWrongGraves(t *testing.T) {
+	c := newGame(t)
+	clickVisitor(t, c)
+	plot := cartridge.VisitorPlots()[0]
+	openPlot(t, c, plot)
+	clickGrave(c, int(plot[0]-'A'), int(plot[1]-'1'))
+	runUntil(t, c, 1, "Lookin' good there, "+plot)
+
+	wrong := unwantedPlot()
+	openPlot(t, c, wrong)
+	clickGrave(c, int(wrong[0]-'A'), int(wrong[1]-'1'))
+	runUntil(t, c, 1, "Rest easy, "+wrong)
+}
+
+func TestReplay(t *testing.T) {
+	cartridge.SetConfigDir(t.TempDir())
+	cartridge.SetSeed(7)
+	c := headless.New()
+	cartridge.UseConsole(c)
+	recording := &bytes.Buffer{}
+	if err := cartridge.Record(recording); err != nil {
+		t.Fatal(err)
+	}
+	cartridge.Start()
+	c.Run(2, cartridge.Update)
+	c.Click(newGameButton)
+	c.Run(2, cartridge.Update)
+	arrive(c)
+	clickVisitor(t, c)
+	clickPlot(c, 0)
+	clickVisitor(t, c)
+	c.Type("A1\n")
+	c.RunScript(cartridge.Update)
+	runUntil(t, c, 2, "They're 100% in A1")
+	want := screen(c)
+
+	rp, err := cartridge.LoadReplay(recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rp.Seed != 7 {
+		t.Fatalf("replay seed %d, want 7", rp.Seed)
+	}
+	c = headless.New()
+	cartridge.UseConsole(c)
+	cartridge.PlayReplay(rp)
+	defer cartridge.KeepSavesOnDisk()
+	cartridge.Start()
+	for !cartridge.ReplayFinished() {
+		c.Run(1, cartridge.Update)
+	}
+	if got := screen(c); got != want {
+		t.Fatalf("replay ended on:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestReplayBringsItsLevel(t *testing.T) {
+	t.Cleanup(cartridge.KeepSavesOnDisk)
+	t.Cleanup(cartridge.PlayMadeUpLevels)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "level.json")
+	if err := os.WriteFile(path, []byte(`{
+		"rows": 1,
+		"columns": 1,
+		"graves": [{"plot": "A1", "relation": "great aunt", "likes": ["They really liked wood."], "digDepth": 1}],
+		"visitors": [{"target": "A1"}]
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cartridge.SetConfigDir(dir)
+	cartridge.SetSeed(1)
+	cartridge.PlayLevel(path)
+	c := headless.New()
+	cartridge.UseConsole(c)
+	recording := &bytes.Buffer{}
+	if err := cartridge.Record(recording); err != nil {
+		t.Fatal(err)
+	}
+	cartridge.Start()
+	c.Run(2, cartridge.Update)
+	c.Click(newGameButton)
+	c.Run(2, cartridge.Update)
+	arrive(c)
+	clickVisitor(t, c)
+	runUntil(t, c, 1, "They really liked wood.")
+	openPlot(t, c, "A1")
+	clickVisitor(t, c)
+	c.Type("A1\n")
+	c.RunScript(cartridge.Update)
+	runUntil(t, c, 2, "They're 100% in A1")
+	want := screen(c)
+
+	// the level's gone, but the replay has a copy.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	rp, err := cartridge.LoadReplay(recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = headless.New()
+	cartridge.UseConsole(c)
+	cartridge.PlayReplay(rp)
+	cartridge.Start()
+	for !cartridge.ReplayFinished() {
+		c.Run(1, cartridge.Update)
+	}
+	if got := screen(c); got != want {
+		t.Fatalf("replay ended on:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// playBot lets the bot play the seed for the number of days.
+func playBot(t *testing.T, seed int64, days int) cartridge.BotReport {
+	t.Helper()
+	t.Cleanup(cartridge.KeepSavesOnDisk)
+	cartridge.SetConfigDir(t.TempDir())
+	cartridge.SetSeed(seed)
+	c := headless.New()
+	cartridge.UseConsole(c)
+	cartridge.PlayBot(days)
+	cartridge.Start()
+	for !cartridge.BotFinished() {
+		c.Step()
+		cartridge.Update()
+	}
+	r := cartridge.BotResult()
+	for _, problem := range r.Problems {
+		t.Errorf("seed %d: %s", seed, problem)
+	}
+	return r
+}
+
+func TestBot(t *testing.T) {
+	r := playBot(t, 1, 2)
+	if r.Found == 0 || r.Wrong > 0 {
+		t.Fatalf("found %d and got %d wrong", r.Found, r.Wrong)
+	}
+}
+
+// TestBotBalance has the bot play the first few seeds on each difficulty,
+// checking they get harder, but not so hard the gravedigger hasn't the
+// stamina to keep up.
+func TestBotBalance(t *testing.T) {
+	t.Cleanup(func() { cartridge.SetDifficulty("Normal") })
+	const seeds, days = 10, 3
+	found := map[string]float64{} // share of visitors given the right plot
+	for _, d := range []string{"Easy", "Normal", "Hard"} {
+		if err := cartridge.SetDifficulty(d); err != nil {
+			t.Fatal(err)
+		}
+		visitors, right, gaveUp, gameOvers := 0, 0, 0, 0
+		for seed := int64(1); seed <= seeds; seed++ {
+			r := playBot(t, seed, days)
+			visitors += r.Found + r.Wrong + r.GaveUp
+			right += r.Found
+			gaveUp += r.GaveUp
+			if r.GameOver {
+				gameOvers++
+			}
+		}
+		found[d] = float64(right) / float64(visitors)
+		t.Logf("%s: found %d of %d, %d gave up, %d game overs", d, right, visitors, gaveUp, gameOvers)
+
+		switch d {
+		case "Easy":
+			if right != visitors {
+				t.Errorf("Easy: found %d of %d, want all of them", right, visitors)
+			}
+		case "Normal":
+			if gameOvers > 0 || gaveUp*10 > visitors {
+				t.Errorf("Normal: %d of %d gave up and %d game overs, want under 1 in 10 and none", gaveUp, visitors, gameOvers)
+			}
+		case "Hard":
+			if gameOvers*2 > seeds {
+				t.Errorf("Hard: %d of %d runs game over, want at most half", gameOvers, seeds)
+			}
+		}
+	}
+	if !(found["Easy"] >= found["Normal"] && found["Normal"] > found["Hard"]) {
+		t.Errorf("found %.2f on Easy, %.2f on Normal and %.2f on Hard, want each harder", found["Easy"], found["Normal"], found["Hard"])
+	}
+}
+
+// level is the bits of a level file the tests look at.
+type level struct {
+	Rows    int
+	Columns int
+	Graves  []struct {
+		Plot     string
+		Relation string
+		Likes    []string
+		DigDepth int
+	}
+	Visitors []struct{ Target string }
+}
+
+func readLevel(t *testing.T, path string) level {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := level{}
+	if err := json.Unmarshal(data, &l); err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestEditorRoundTrip(t *testing.T) {
+	t.Cleanup(cartridge.StopEditing)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "level.json")
+	if err := os.WriteFile(path, []byte(`{
+		"rows": 1,
+		"graves": [{"plot": "A2", "relation": "evil twin", "likes": ["They really liked wood."], "digDepth": 4}],
+		"visitors": [{"target": "A2"}]
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cartridge.SetConfigDir(dir)
+	cartridge.SetSeed(1)
+	c := headless.New()
+	cartridge.UseConsole(c)
+	cartridge.EditLevel(path)
+	cartridge.Start()
+	c.Run(2, cartridge.Update)
+	runUntil(t, c, 1, "Level Editor 1 x 5")
+
+	save := image.Point{X: 42 * 6, Y: 4}
+	c.Click(image.Point{X: 29 * 6, Y: 12}) // Rows +
+	c.Run(1, cartridge.Update)
+	c.Click(image.Point{X: 38 * 6, Y: 12}) // Cols -
+	c.Run(1, cartridge.Update)
+	c.Click(save)
+	c.Run(1, cartridge.Update)
+	runUntil(t, c, 1, " Saved ")
+
+	l := readLevel(t, path)
+	if l.Rows != 2 || l.Columns != 4 || len(l.Graves) != 8 {
+		t.Fatalf("saved %d x %d with %d graves, want 2 x 4 with 8", l.Rows, l.Columns, len(l.Graves))
+	}
+	if len(l.Visitors) != 1 || l.Visitors[0].Target != "A2" {
+		t.Fatalf("saved visitors %+v, want one looking for A2", l.Visitors)
+	}
+	for _, g := range l.Graves {
+		if g.Plot == "A2" && (g.Relation != "evil twin" || len(g.Likes) != 1 || g.Likes[0] != "They really liked wood." || g.DigDepth != 4) {
+			t.Fatalf("A2 saved as %+v", g)
+		}
+	}
+
+	// loading what was saved and saving it again changes nothing.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cartridge.StopEditing()
+	c = headless.New()
+	cartridge.UseConsole(c)
+	cartridge.EditLevel(path)
+	cartridge.Start()
+	c.Run(2, cartridge.Update)
+	runUntil(t, c, 1, "Level Editor 2 x 4")
+	c.Click(save)
+	c.Run(1, cartridge.Update)
+	runUntil(t, c, 1, " Saved ")
+	again, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Fatalf("saved again as:\n%s\nwant:\n%s", again, data)
+	}
+}
+
+func TestOnlyDesignedLookalikes(t *testing.T) {
+	t.Cleanup(cartridge.PlayMadeUpLevels)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "level.json")
+	if err := os.WriteFile(path, []byte(`{
+		"rows": 6,
+		"graves": [
+			{"plot": "A1", "relation": "great aunt", "likes": ["They really liked wood."], "digDepth": 1},
+			{"plot": "C3", "relation": "great uncle", "likes": ["They really liked wood."], "digDepth": 1}
+		],
+		"visitors": [{"target": "A1"}]
+	}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cartridge.PlayLevel(path)
+	newGameIn(t, dir, 1)
+	plots := cartridge.MatchingPlots([]string{"They really liked wood."})
+	if fmt.Sprint(plots) != "[A1 C3]" {
+		t.Fatalf("%v fit the clues, only the level's A1 and C3 should", plots)
+	}
+}
+
+func TestDifficulty(t *testing.T) {
+	t.Cleanup(func() { cartridge.SetDifficulty("Normal") })
+	cartridge.SetConfigDir(t.TempDir())
+	cartridge.SetSeed(1)
+	c := headless.New()
+	cartridge.UseConsole(c)
+	cartridge.Start()
+	c.Run(2, cartridge.Update)
+	c.Click(image.Point{X: 15 * 6, Y: 22*8 + 4}) // Easy
+	c.Run(1, cartridge.Update)
+	c.Click(newGameButton)
+	c.Run(2, cartridge.Update)
+	arrive(c)
+	runUntil(t, c, 1, "Mode Easy")
+	if n := len(cartridge.VisitorPlots()); n != 3 {
+		t.Fatalf("%d visitors on Easy, want 3", n)
+	}
+	finishDay(t, c)
+
+	// Continue picks up on Easy, whatever the titles were left on.
+	if err := cartridge.SetDifficulty("Hard"); err != nil {
+		t.Fatal(err)
+	}
+	c = continueGame(t)
+	runUntil(t, c, 1, "Mode Easy")
+}
+
+func TestHardHasLookalikes(t *testing.T) {
+	if err := cartridge.SetDifficulty("Hard"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cartridge.SetDifficulty("Normal") })
+
+	ambiguous := 0
+	for seed := int64(1); seed <= 10; seed++ {
+		newGameSeed(t, seed)
+		clues := cartridge.VisitorClues()
+		for i, likes := range clues[:len(clues)-1] {
+			if plots := cartridge.MatchingPlots(likes); len(plots) != 1 {
+				t.Fatalf("seed %d visitor %d's clues fit %v", seed, i+1, plots)
+			}
+		}
+		if len(cartridge.MatchingPlots(clues[len(clues)-1])) > 1 {
+			ambiguous++
+		}
+	}
+	if ambiguous == 0 {
+		t.Fatal("the last visitor always had just the one grave")
+	}
+}
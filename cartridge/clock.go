@@ -0,0 +1,83 @@
+package cartridge
+
+import "fmt"
+
+const (
+	dayLengthFrames = 5 * 60 * framesPerSecond
+	dayStartHour    = 8
+	dayEndHour      = 18
+
+	// palettes in resources/1_palettes.png and 2_palettes.png (the
+	// graveyard and people banks), from full daylight (0) to dusk.
+	paletteDay  = 0
+	paletteDusk = 3
+	// the light starts to go once this much of the day has passed.
+	duskFrom = dayLengthFrames * 3 / 5
+)
+
+// tintedSprites are the sprites that darken as the day ends, the text
+// layer and mouse pointer stay readable.
+var tintedSprites = []int{
+	SpriteGraveyardUnderlay,
+	SpriteGraveyard,
+	SpriteGraveyardOverlay,
+	SpriteVisitor1,
+	SpriteVisitor2,
+	SpriteVisitor3,
+	SpriteVisitor4,
+	SpriteVisitor5,
+	SpritePlayer,
+}
+
+// DayClock is how far through the working day we are. When it runs out
+// anyone still waiting gives up and goes home.
+type DayClock struct {
+	Frame   int
+	palette int
+}
+
+func NewDayClock() *DayClock {
+	return &DayClock{palette: -1}
+}
+
+func (c *DayClock) Over() bool {
+	return c.Frame >= dayLengthFrames
+}
+
+func (c *DayClock) minutes() int {
+	return dayStartHour*60 + c.Frame*(dayEndHour-dayStartHour)*60/dayLengthFrames
+}
+
+func (c *DayClock) String() string {
+	m := c.minutes()
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
+
+// Palette is the palette the graveyard should be drawn with right now.
+func (c *DayClock) Palette() int {
+	if c.Frame < duskFrom {
+		return paletteDay
+	}
+	p := paletteDay + 1 + (c.Frame-duskFrom)*(paletteDusk-paletteDay)/(dayLengthFrames-duskFrom)
+	if p > paletteDusk {
+		p = paletteDusk
+	}
+	return p
+}
+
+func (c *DayClock) Update() {
+	if !c.Over() {
+		before := c.minutes()
+		c.Frame++
+		if c.minutes()/5 != before/5 {
+			text.HUD()
+		}
+	}
+
+	if p := c.Palette(); p != c.palette {
+		c.palette = p
+		for _, id := range tintedSprites {
+			console.SpritesGet(id).ChangePalette(p)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/png"
+)
+
+const (
+	cluesFile         = "resources/clues.json"
+	graveyardGfxFile  = "resources/1_8x8.png"
+	graveyardTileSize = 8
+	defaultDigDepth   = 10
+	maxDigDepth       = 20 // the digging phrases are spread over however deep it is
+	defaultClueCount  = 3
+)
+
+// clues is the layout of resources/clues.json. Each trait category brings
+// its own row of likes, see TraitCategory.
+type clues struct {
+	Relations      []string         `json:"relations"`
+	DiggingPhrases [][]string       `json:"diggingPhrases"`
+	Traits         []*TraitCategory `json:"traits"`
+}
+
+// loadClues reads and validates the clue and trait tables.
+func loadClues() error {
+	b, err := Resources.ReadFile(cluesFile)
+	if err != nil {
+		return err
+	}
+	c := clues{}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return fmt.Errorf("%s: %w", cluesFile, err)
+	}
+	sheet, err := graveyardSheetSize()
+	if err != nil {
+		return err
+	}
+	if err := c.validate(sheet); err != nil {
+		return fmt.Errorf("%s: %w", cluesFile, err)
+	}
+
+	Relations = c.Relations
+	DiggingPhrases = c.DiggingPhrases
+	TraitCategories = c.Traits
+	Likes = [][]string{}
+	for _, category := range TraitCategories {
+		Likes = append(Likes, category.likes())
+	}
+	return nil
+}
+
+// graveyardSheetSize is the size of the graveyard gfx bank in tiles.
+func graveyardSheetSize() (image.Point, error) {
+	f, err := Resources.Open(graveyardGfxFile)
+	if err != nil {
+		return image.Point{}, err
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return image.Point{}, fmt.Errorf("%s: %w", graveyardGfxFile, err)
+	}
+	return image.Point{X: cfg.Width / graveyardTileSize, Y: cfg.Height / graveyardTileSize}, nil
+}
+
+func (c *clues) validate(sheet image.Point) error {
+	if len(c.Relations) == 0 {
+		return fmt.Errorf("no relations")
+	}
+	if len(c.DiggingPhrases) < defaultDigDepth {
+		return fmt.Errorf("need %d rows of digging phrases, got %d", defaultDigDepth, len(c.DiggingPhrases))
+	}
+	for i, phrases := range c.DiggingPhrases {
+		if len(phrases) == 0 {
+			return fmt.Errorf("digging phrases row %d is empty", i)
+		}
+	}
+	if len(c.Traits) < maxClues {
+		return fmt.Errorf("need at least %d trait categories, got %d", maxClues, len(c.Traits))
+	}
+
+	names := map[string]bool{}
+	seen := map[string]bool{}
+	for _, category := range c.Traits {
+		if category.Name == "" || names[category.Name] {
+			return fmt.Errorf("trait categories need a unique name, got %q", category.Name)
+		}
+		names[category.Name] = true
+		if err := category.validate(sheet); err != nil {
+			return fmt.Errorf("%s: %w", category.Name, err)
+		}
+		// a like can only be a clue in one place, or graves couldn't tell
+		// which trait to show for it.
+		for _, clue := range category.Clues {
+			if seen[clue.Text] {
+				return fmt.Errorf("%q is listed twice", clue.Text)
+			}
+			seen[clue.Text] = true
+		}
+	}
+	return nil
+}
+
+func (c *TraitCategory) validate(sheet image.Point) error {
+	if c.Layer != traitLayerGraveyard && c.Layer != traitLayerOverlay {
+		return fmt.Errorf("layer must be %q or %q, got %q", traitLayerGraveyard, traitLayerOverlay, c.Layer)
+	}
+	if c.Size.X <= 0 || c.Size.Y <= 0 {
+		return fmt.Errorf("bad size %v", c.Size)
+	}
+	if !c.Closed && !c.Open {
+		return fmt.Errorf("never shown, needs closed or open")
+	}
+	if len(c.Default) == 0 {
+		return fmt.Errorf("no default tiles")
+	}
+	if len(c.Clues) == 0 {
+		return fmt.Errorf("no clues")
+	}
+	for _, clue := range c.Clues {
+		if clue.Text == "" {
+			return fmt.Errorf("a clue has no text")
+		}
+		for _, tile := range clue.Tiles {
+			if err := checkTiles(sheet, clue.Text, tile.X, tile.Y, c.Size.X, c.Size.Y); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkTiles(sheet image.Point, like string, x, y, w, h int) error {
+	block := image.Rect(x, y, x+w, y+h)
+	if !block.In(image.Rectangle{Max: sheet}) {
+		return fmt.Errorf("%q uses tiles %v, outside the %dx%d tile graveyard bank", like, block, sheet.X, sheet.Y)
+	}
+	return nil
+}
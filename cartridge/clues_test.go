@@ -0,0 +1,51 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestCluesValidate(t *testing.T) {
+	sheet, err := graveyardSheetSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Resources.ReadFile(cluesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name   string
+		change func(c *clues)
+		want   string
+	}{
+		{"as shipped", func(c *clues) {}, ""},
+		{"no relations", func(c *clues) { c.Relations = nil }, "no relations"},
+		{"short of digging phrases", func(c *clues) { c.DiggingPhrases = c.DiggingPhrases[:3] }, "rows of digging phrases"},
+		{"no trait categories", func(c *clues) { c.Traits = c.Traits[:2] }, "trait categories"},
+		{"two categories with a name", func(c *clues) { c.Traits[1].Name = c.Traits[0].Name }, "unique name"},
+		{"a like in two places", func(c *clues) { c.Traits[1].Clues = append(c.Traits[1].Clues, c.Traits[0].Clues[0]) }, "listed twice"},
+		{"unknown layer", func(c *clues) { c.Traits[0].Layer = "sky" }, "layer must be"},
+		{"never shown", func(c *clues) { c.Traits[0].Closed, c.Traits[0].Open = false, false }, "never shown"},
+		{"no default", func(c *clues) { c.Traits[0].Default = nil }, "no default"},
+		{"tiles off the sheet", func(c *clues) { c.Traits[0].Clues[0].Tiles[0] = image.Point{X: sheet.X} }, "outside"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := clues{}
+			if err := json.Unmarshal(b, &c); err != nil {
+				t.Fatal(err)
+			}
+			test.change(&c)
+			err := c.validate(sheet)
+			if test.want == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("got %v, want an error about %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package cartridge
+
+import "image"
+
+// Console is everything the cartridge needs from the fantasy console. The
+// cartridge doesn't know which one it's running on: cmd/losttheplot hands it
+// marv, and the headless package an in-memory one so Start and Update can run
+// without a window.
+type Console interface {
+	SpritesGet(id int) Sprite
+	SpritesSort()
+	MapBanksGet(id int) MapBank
+	ModBanksGet(id int) ModBank
+	InputMousePressed() bool
+	InputMousePos() image.Point
+	// InputChars is what was typed this frame, with Backspace as '\b' and
+	// Enter as '\n'.
+	InputChars() []rune
+}
+
+type Sprite interface {
+	Show(gfxBank int, area MapBankArea)
+	Hide()
+	ChangePos(pos image.Rectangle)
+	ChangeViewport(viewport image.Point)
+	SetSortIdx(idx int)
+	ChangePalette(palette int)
+}
+
+type MapBank interface {
+	AllocArea(size image.Point) MapBankArea
+}
+
+type ModBank interface {
+	// PlayFrom loops the song starting at the given song position.
+	PlayFrom(position int)
+	Stop()
+	SetVolume(volume float64)
+	// PlaySample plays a single instrument over the music, note is in
+	// semitones from the sample's own pitch.
+	PlaySample(sample, note int, volume float64)
+}
+
+type MapBankArea interface {
+	Set(pos image.Point, x, y, fg, bg uint8)
+	Clear(x, y uint8)
+	StringToMap(pos image.Point, fg, bg uint8, txt string)
+}
+
+var console Console
+
+// UseConsole sets the console the cartridge talks to. Call it before Start.
+func UseConsole(c Console) {
+	console = c
+}
@@ -0,0 +1,121 @@
+package cartridge
+
+import (
+	"fmt"
+	"image"
+)
+
+// Difficulty is a preset, picked at the titles, for how the run's made up
+// graveyards are laid out and how harshly mistakes are punished. Hand made
+// levels keep their own graves and visitors, but the penalties still apply.
+type Difficulty struct {
+	Name          string
+	Clues         int // likes a made up grave starts with
+	DigDepth      int // spadefuls to open a made up grave
+	Stamina       int // spadefuls the gravedigger has in them a day
+	Visitors      int // a made up day
+	Ambiguous     int // visitors a day whose clues fit a lookalike as well as their grave
+	AngryCost     int // reputation lost to a wrong plot, or a visitor giving up
+	OpenGraveCost int // reputation lost to each wrongly opened grave every so often
+	ClosingCost   int // reputation lost once if anyone's still waiting at dusk
+}
+
+var difficulties = []Difficulty{
+	{
+		Name:          "Easy",
+		Clues:         4,
+		DigDepth:      6,
+		Stamina:       100,
+		Visitors:      3,
+		AngryCost:     15,
+		OpenGraveCost: 1,
+		ClosingCost:   5,
+	},
+	{
+		Name:          "Normal",
+		Clues:         defaultClueCount,
+		DigDepth:      defaultDigDepth,
+		Stamina:       staminaPerDay,
+		Visitors:      maxVisitors,
+		AngryCost:     -reputationAngry,
+		OpenGraveCost: openGravePenalty,
+		ClosingCost:   -reputationClosing,
+	},
+	{
+		Name:          "Hard",
+		Clues:         2,
+		DigDepth:      14,
+		Stamina:       180,
+		Visitors:      maxVisitors,
+		Ambiguous:     1,
+		AngryCost:     35,
+		OpenGraveCost: 3,
+		ClosingCost:   20,
+	},
+}
+
+// difficulty is the run's preset, chosen at the titles for a new game or
+// taken from the save.
+var difficulty = &difficulties[1]
+
+// difficultyNamed returns the preset with the name, or nil.
+func difficultyNamed(name string) *Difficulty {
+	for i := range difficulties {
+		if difficulties[i].Name == name {
+			return &difficulties[i]
+		}
+	}
+	return nil
+}
+
+// SetDifficulty picks the preset (Easy, Normal or Hard) new games start
+// with, rather than Normal. Call it before Start.
+func SetDifficulty(name string) error {
+	d := difficultyNamed(name)
+	if d == nil {
+		return fmt.Errorf("no %q difficulty, there's Easy, Normal and Hard", name)
+	}
+	difficulty = d
+	return nil
+}
+
+// difficultyButtons are where the titles lay out the presets to pick from,
+// on the row above New Game.
+func difficultyButtons() []Hitbox {
+	buttons := []Hitbox{}
+	x := 14
+	for _, d := range difficulties {
+		w := len(d.Name) + 2
+		buttons = append(buttons, Hitbox{
+			Rectangle: image.Rectangle{
+				Min: image.Point{X: x * 6, Y: 22 * 8},
+				Max: image.Point{X: (x + w) * 6, Y: 23 * 8},
+			},
+		})
+		x += w + 2
+	}
+	return buttons
+}
+
+// DifficultyMenu shows the presets, the chosen one picked out.
+func (t *Text) DifficultyMenu() {
+	for i, b := range difficultyButtons() {
+		fg, bg := uint8(10), uint8(16)
+		if &difficulties[i] == difficulty {
+			fg, bg = 7, 12
+		}
+		areaText.StringToMap(image.Point{X: b.Min.X / 6, Y: 22}, fg, bg, " "+difficulties[i].Name+" ")
+	}
+}
+
+// updateDifficultyMenu picks the preset clicked on, if any.
+func updateDifficultyMenu(mousePos image.Point) bool {
+	for i, b := range difficultyButtons() {
+		if b.IsHitNoCameraOffset(mousePos) {
+			difficulty = &difficulties[i]
+			text.DifficultyMenu()
+			return true
+		}
+	}
+	return false
+}
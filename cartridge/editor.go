@@ -0,0 +1,502 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// The level editor lays the graveyard out as it would be for a day's play,
+// but clicking a grave opens its sheet, where clicking a line changes it:
+// the relation, how deep it's buried, the clue shown for each trait and
+// which visitor (if any) is looking for it. The level can be saved to its
+// file, or played straight away and come back to once the day's over.
+
+var editPath string
+
+// EditLevel starts in the level editor rather than at the titles, editing
+// the level file (which needn't exist yet). Call it before Start.
+func EditLevel(path string) {
+	editPath = path
+	savesInMemory = true // playing a level to try it out isn't a real run.
+}
+
+type Editor struct {
+	level    *levelFile                   // as it was last laid out, nil for a new one
+	targets  [maxVisitors]*Grave          // who each visitor is looking for
+	dialogue [maxVisitors]visitorDialogue // what each visitor says, kept from the file
+	selected *Grave                       // the grave whose sheet is open
+	look     image.Point                  // where the camera's looking
+	status   string                       // said along the bottom until the next change
+}
+
+var editor *Editor
+
+var editorButtons = []struct {
+	x, y   int
+	label  string
+	action func(e *Editor)
+}{
+	{41, 0, " Save ", (*Editor).save},
+	{48, 0, " Play ", (*Editor).play},
+	{19, 1, " Rows - ", func(e *Editor) { e.resize(-1, 0) }},
+	{28, 1, " Rows + ", func(e *Editor) { e.resize(1, 0) }},
+	{37, 1, " Cols - ", func(e *Editor) { e.resize(0, -1) }},
+	{46, 1, " Cols + ", func(e *Editor) { e.resize(0, 1) }},
+}
+
+func setupEditor() {
+	if editor == nil {
+		editor = &Editor{look: fullScreenRect.Max.Div(2)}
+		if l, err := loadLevel(editPath); err == nil {
+			editor.level = l
+		} else if !errors.Is(err, os.ErrNotExist) {
+			log.Println("edit:", err)
+			editor.status = "Couldn't load " + editPath + ", starting afresh."
+		}
+	}
+
+	for id := SpriteGraveyardUnderlay; id <= SpriteMousePointer; id++ {
+		console.SpritesGet(id).Hide()
+		console.SpritesGet(id).ChangePalette(paletteDay)
+	}
+	audio.StopMusic()
+	lvlNum = 1
+	seedLevel(lvlNum)
+	scheduler.Clear()
+	camera = NewCamera()
+	camera.LookAt(float64(editor.look.X), float64(editor.look.Y))
+	clock = NewDayClock()
+	visitors = &Visitors{}
+	editor.load()
+	text = NewText()
+	editor.selected = nil
+	editor.draw()
+
+	mode = MODE_EDITOR
+}
+
+// load lays out the graveyard from the level, or a made up one-row
+// graveyard if there's no level yet.
+func (e *Editor) load() {
+	e.targets = [maxVisitors]*Grave{}
+	if e.level == nil {
+		graveyard = &Graveyard{}
+		graveyard.Setup(1, maxCols)
+		return
+	}
+	byPlot := e.level.setupGraveyard()
+	for i, v := range e.level.Visitors {
+		e.targets[i] = byPlot[v.Target]
+		e.dialogue[i] = v.visitorDialogue
+	}
+}
+
+func (e *Editor) levelFile() *levelFile {
+	l := newLevelFile(graveyard)
+	if e.level != nil {
+		l.Name = e.level.Name
+	}
+	for i, grave := range e.targets {
+		if grave != nil {
+			l.Visitors = append(l.Visitors, levelVisitor{Target: grave.Plot(), visitorDialogue: e.dialogue[i]})
+		}
+	}
+	return l
+}
+
+// resize grows or shrinks the graveyard by rows and columns, keeping the
+// graves (and who's looking for them) that are still in it.
+func (e *Editor) resize(dRows, dCols int) {
+	rows, cols := graveyard.rows+dRows, graveyard.cols+dCols
+	if rows < 1 || rows > maxLevelRows {
+		e.status = fmt.Sprintf("The graveyard has 1 to %d rows.", maxLevelRows)
+		return
+	}
+	if cols < 1 || cols > maxCols {
+		e.status = fmt.Sprintf("The graveyard has 1 to %d columns.", maxCols)
+		return
+	}
+	targets := [maxVisitors]string{}
+	for i, g := range e.targets {
+		if g != nil {
+			targets[i] = g.Plot()
+		}
+	}
+
+	l := e.levelFile()
+	l.Rows, l.Columns = rows, cols
+	valid := plots(rows, cols)
+	graves := []levelGrave{}
+	for _, g := range l.Graves {
+		if valid[g.Plot] {
+			graves = append(graves, g)
+		}
+	}
+	l.Graves = graves
+	l.Visitors = nil
+	e.level = l
+	e.load()
+
+	for _, g := range graveyard.graves {
+		for i, plot := range targets {
+			if plot == g.Plot() {
+				e.targets[i] = g
+			}
+		}
+	}
+	e.status = fmt.Sprintf("%d rows of %d.", rows, cols)
+}
+
+// checked is the level as it stands, or nil (and why in the status) if it
+// can't be played yet.
+func (e *Editor) checked() *levelFile {
+	l := e.levelFile()
+	if len(l.Visitors) == 0 {
+		e.status = "Nobody's visiting, give a grave a visitor first."
+		return nil
+	}
+	if err := l.validate(); err != nil {
+		e.status = err.Error()
+		return nil
+	}
+	return l
+}
+
+func (e *Editor) save() {
+	l := e.checked()
+	if l == nil {
+		return
+	}
+	if err := l.save(editPath); err != nil {
+		log.Println("edit:", err)
+		e.status = "Couldn't save " + editPath + "."
+		return
+	}
+	e.level = l
+	e.status = "Saved " + editPath + "."
+}
+
+// play tries the level out, coming back to the editor once the day's over.
+func (e *Editor) play() {
+	l := e.checked()
+	if l == nil {
+		return
+	}
+	e.level = l
+	levels = []*levelFile{l}
+	newRun()
+	mode = MODE_GAME_SETUP
+}
+
+// ambiguous says which visitors' clues fit more than the grave they're
+// looking for, as they'd have to be guessed.
+func (e *Editor) ambiguous() string {
+	notes := []string{}
+	for i, g := range e.targets {
+		if g == nil {
+			continue
+		}
+		if n := len(graveyard.MatchingGraves(g.Likes)); n > 1 {
+			notes = append(notes, fmt.Sprintf("visitor %d's clues fit %d graves", i+1, n))
+		}
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	s := strings.Join(notes, ", ") + "."
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func (e *Editor) draw() {
+	text.Clear(0, 0, 54, 25)
+	areaText.StringToMap(image.Point{X: 1, Y: 0}, 7, 12, fmt.Sprintf(" Level Editor %d x %d ", graveyard.rows, graveyard.cols))
+	for _, b := range editorButtons {
+		areaText.StringToMap(image.Point{X: b.x, Y: b.y}, 7, 12, b.label)
+	}
+	status := e.status
+	if status == "" {
+		status = e.ambiguous()
+	}
+	if status == "" {
+		status = "Click a grave to edit it, WASD to look around."
+	}
+	if len(status) > 52 {
+		status = status[:49] + "..."
+	}
+	areaText.StringToMap(image.Point{X: 1, Y: 24}, 7, 12, " "+status+" ")
+}
+
+// showVisitors stands each visitor beside the grave they're looking for.
+func (e *Editor) showVisitors() {
+	cp := camera.GetAsPoint()
+	for i, g := range e.targets {
+		s := console.SpritesGet(SpriteVisitor1 + i)
+		if g == nil || e.selected != nil {
+			s.Hide()
+			continue
+		}
+		v := Visitor{SpriteId: SpriteVisitor1 + i, Grave: g, w: 4 * 8, h: 8 * 8}
+		v.setFeet(v.graveside())
+		s.Show(GfxBankPeople, areaPeople)
+		s.ChangeViewport(image.Point{X: (3 + i%4) * 32, Y: 0})
+		s.ChangePos(image.Rectangle{Min: v.Pos.Sub(cp), Max: image.Point{X: v.w, Y: v.h}})
+		s.SetSortIdx(v.Pos.Y)
+	}
+	console.SpritesSort()
+}
+
+func updateEditor() {
+	console.SpritesGet(SpriteMousePointer).ChangePos(image.Rectangle{
+		Min: console.InputMousePos(),
+		Max: image.Point{X: 32, Y: 32},
+	})
+	camera.Update()
+	graveyard.Update()
+	editor.showVisitors()
+
+	if editor.selected != nil {
+		editor.updateSheet()
+	} else {
+		editor.updateView()
+	}
+}
+
+// updateView scrolls around the graveyard and picks graves to edit.
+func (e *Editor) updateView() {
+	size := graveyard.Size()
+	for _, c := range console.InputChars() {
+		switch c {
+		case 'w', 'W':
+			e.look.Y -= 32
+		case 's', 'S':
+			e.look.Y += 32
+		case 'a', 'A':
+			e.look.X -= 32
+		case 'd', 'D':
+			e.look.X += 32
+		}
+		// no further than the camera can go.
+		half := fullScreenRect.Max.Div(2)
+		e.look.X = clampInt(e.look.X, half.X, size.X-half.X)
+		e.look.Y = clampInt(e.look.Y, half.Y, size.Y-half.Y)
+		camera.LookAt(float64(e.look.X), float64(e.look.Y))
+	}
+
+	if !console.InputMousePressed() {
+		return
+	}
+	mousePos := console.InputMousePos()
+	if mousePos.Y < 16 {
+		for _, b := range editorButtons {
+			if mousePos.Y/8 == b.y && mousePos.X >= b.x*6 && mousePos.X < (b.x+len(b.label))*6 {
+				e.status = ""
+				b.action(e)
+				if mode == MODE_EDITOR {
+					e.draw()
+				}
+				return
+			}
+		}
+	}
+	if g := graveyard.GetClickedGrave(mousePos); g != nil {
+		e.selected = g
+		e.status = ""
+		e.drawSheet()
+	}
+}
+
+func clampInt(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
+
+// sheetLine is a line on a grave's sheet, clicking it moves on to the next
+// value.
+type sheetLine struct {
+	label  string
+	value  string
+	change func()
+}
+
+func (e *Editor) sheetLines(g *Grave) []sheetLine {
+	visitor := "nobody"
+	if slot := e.visitorFor(g); slot >= 0 {
+		visitor = fmt.Sprintf("visitor %d", slot+1)
+	}
+	lines := []sheetLine{
+		{"Relation", g.Relation, func() { e.nextRelation(g) }},
+		{"Dig depth", fmt.Sprint(g.DigDepth), func() { g.DigDepth = g.DigDepth%maxDigDepth + 1 }},
+		{"Looked for by", visitor, func() { e.nextVisitor(g) }},
+	}
+	for cat, category := range TraitCategories {
+		cat := cat
+		value := "nothing of note"
+		if like := g.like(cat); like != "" {
+			value = shortLike(like)
+		}
+		lines = append(lines, sheetLine{category.Name, value, func() { e.nextLike(g, cat) }})
+	}
+	return lines
+}
+
+// sheetLineY is the text row a sheet line is on, with a gap before the
+// traits.
+func sheetLineY(i int) int {
+	if i >= 3 {
+		i++
+	}
+	return notebookTop + i
+}
+
+func (e *Editor) drawSheet() {
+	g := e.selected
+	text.SpeechBox(0, 0, 54, 25, 12)
+	areaText.StringToMap(image.Point{X: 2, Y: 0}, 7, 12, " Plot "+g.Plot()+" ")
+	areaText.StringToMap(image.Point{X: 46, Y: 0}, 7, 12, " Done ")
+
+	lines := e.sheetLines(g)
+	for i, line := range lines {
+		y := sheetLineY(i)
+		areaText.StringToMap(image.Point{X: notebookCol1, Y: y}, 7, 12, fmt.Sprintf(" %-14s", line.label))
+		areaText.StringToMap(image.Point{X: notebookCol1 + 16, Y: y}, 10, 7, line.value)
+	}
+
+	y := sheetLineY(len(lines)) + 1
+	areaText.StringToMap(image.Point{X: notebookCol1, Y: y}, 10, 7, fmt.Sprintf("A visitor would give %d of at most %d clues.", len(g.Likes), maxClues))
+	fits := "Nothing else fits their clues."
+	if matches := graveyard.MatchingPlots(g.Likes); len(matches) > 1 {
+		fits = "Their clues also fit " + strings.Join(removeString(matches, g.Plot()), ", ") + "."
+	}
+	areaText.StringToMap(image.Point{X: notebookCol1, Y: y + 1}, 10, 7, fits)
+
+	status := e.status
+	if status == "" {
+		status = "Click a line to change it."
+	}
+	areaText.StringToMap(image.Point{X: 2, Y: 24}, 7, 12, " "+status+" ")
+}
+
+func removeString(list []string, s string) []string {
+	kept := []string{}
+	for _, item := range list {
+		if item != s {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+func (e *Editor) updateSheet() {
+	if !console.InputMousePressed() {
+		return
+	}
+	mousePos := console.InputMousePos()
+	if mousePos.Y < 8 && mousePos.X >= 46*6 && mousePos.X < 52*6 {
+		e.selected = nil
+		e.status = ""
+		e.draw()
+		return
+	}
+	for i, line := range e.sheetLines(e.selected) {
+		y := sheetLineY(i)
+		if mousePos.Y >= y*8 && mousePos.Y < (y+1)*8 && mousePos.X >= notebookCol1*6 && mousePos.X < 52*6 {
+			e.status = ""
+			line.change()
+			e.selected.draw()
+			text.Clear(0, 0, 54, 25)
+			e.drawSheet()
+			return
+		}
+	}
+}
+
+func (e *Editor) nextRelation(g *Grave) {
+	for i, r := range Relations {
+		if r == g.Relation {
+			g.Relation = Relations[(i+1)%len(Relations)]
+			return
+		}
+	}
+	g.Relation = Relations[0]
+}
+
+// visitorFor is which visitor is looking for the grave, or -1.
+func (e *Editor) visitorFor(g *Grave) int {
+	for i, target := range e.targets {
+		if target == g {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextVisitor moves on to the next visitor who isn't already looking for
+// someone else, or nobody.
+func (e *Editor) nextVisitor(g *Grave) {
+	slot := e.visitorFor(g)
+	if slot >= 0 {
+		e.targets[slot] = nil
+	}
+	for next := slot + 1; next < maxVisitors; next++ {
+		if e.targets[next] == nil {
+			e.targets[next] = g
+			return
+		}
+	}
+}
+
+// like is the grave's like for the trait category, if it has one.
+func (g *Grave) like(cat int) string {
+	for _, like := range g.Likes {
+		if likeCategory(like) == cat {
+			return like
+		}
+	}
+	return ""
+}
+
+// nextLike moves the grave on to the category's next clue, or none after
+// the last. A grave can't have more clues than a visitor can give.
+func (e *Editor) nextLike(g *Grave, cat int) {
+	options := Likes[cat]
+	current := g.like(cat)
+	next := ""
+	if current == "" {
+		if len(g.Likes) >= maxClues {
+			e.status = fmt.Sprintf("A visitor can only give %d clues.", maxClues)
+			return
+		}
+		next = options[0]
+	} else {
+		for i, like := range options {
+			if like == current && i+1 < len(options) {
+				next = options[i+1]
+			}
+		}
+	}
+
+	likes := []string{}
+	for _, like := range g.Likes {
+		if like != current {
+			likes = append(likes, like)
+		}
+	}
+	if next != "" {
+		likes = append(likes, next)
+	}
+	sort.SliceStable(likes, func(i, j int) bool {
+		return likeCategory(likes[i]) < likeCategory(likes[j])
+	})
+	g.Likes = likes
+	g.generateTraits()
+}
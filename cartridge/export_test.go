@@ -0,0 +1,82 @@
+package cartridge
+
+import "image"
+
+// VisitorClues are the clues each of today's visitors gives.
+func VisitorClues() [][]string {
+	clues := [][]string{}
+	for _, v := range visitors.Visitors {
+		clues = append(clues, v.Grave.Likes)
+	}
+	return clues
+}
+
+// VisitorPlots are the plots today's visitors are looking for.
+func VisitorPlots() []string {
+	plots := []string{}
+	for _, v := range visitors.Visitors {
+		plots = append(plots, v.Grave.Plot())
+	}
+	return plots
+}
+
+// CameraPos is where the graveyard's scrolled to, for tests to click on it.
+func CameraPos() image.Point {
+	return camera.GetAsPoint()
+}
+
+// StartLevel starts the day over at the given level.
+func StartLevel(lvl int) {
+	lvlNum = lvl
+	setupGame()
+}
+
+// Digging is whether the gravedigger is on their way to, or digging, a grave.
+func Digging() bool {
+	return player.digTarget != nil
+}
+
+// WallOff blocks every path through the graveyard.
+func WallOff() {
+	for i := range graveyard.walkGrid.blocked {
+		graveyard.walkGrid.blocked[i] = true
+	}
+}
+
+// VisitorsArrived is whether all of today's visitors have come in and are
+// waiting to be talked to.
+func VisitorsArrived() bool {
+	for _, v := range visitors.Visitors {
+		if v.state != visitorWaiting {
+			return false
+		}
+	}
+	return true
+}
+
+// KeepSavesOnDisk undoes PlayReplay keeping saves in memory, for the tests
+// after a replay.
+func KeepSavesOnDisk() {
+	savesInMemory = false
+	memorySave = nil
+}
+
+// StopEditing undoes EditLevel, for the tests that follow.
+func StopEditing() {
+	editPath = ""
+	editor = nil
+	KeepSavesOnDisk()
+}
+
+// PlayMadeUpLevels undoes PlayLevel, PlayLevelPack and a replay's levels,
+// for the tests that follow.
+func PlayMadeUpLevels() {
+	levelPaths = nil
+	levelJSON = nil
+	packOnly = false
+}
+
+// MatchingPlots is every plot in today's graveyard that fits the clues.
+func MatchingPlots(likes []string) []string {
+	return graveyard.MatchingPlots(likes)
+}
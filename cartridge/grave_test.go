@@ -0,0 +1,60 @@
+package cartridge
+
+import (
+	"image"
+	"testing"
+)
+
+func TestDigHole(t *testing.T) {
+	if err := loadClues(); err != nil {
+		t.Fatal(err)
+	}
+	size := image.Point{X: 16, Y: 16}
+	area, areaOverlay = NewTileMap(size, nowhere{}), NewTileMap(size, nowhere{})
+	for _, test := range []struct {
+		depth  int
+		stages []int // the stage shown after each stroke but the last
+	}{
+		{3, []int{0, 2}},
+		{5, []int{0, 1, 2, 3}},
+		{9, []int{0, 0, 1, 1, 2, 2, 3, 3}},
+	} {
+		g := &Grave{MapX: 4, MapY: 2, DigDepth: test.depth}
+		g.generateTraits()
+		for i, want := range test.stages {
+			g.clickcounter = i + 1
+			g.draw()
+			if x, y, _, _ := area.Get(image.Point{X: 4, Y: 6}); x != uint8(want*4) || y != digHoleTileY {
+				t.Fatalf("depth %d stroke %d: hole tile %d,%d, want %d,%d", test.depth, i+1, x, y, want*4, digHoleTileY)
+			}
+			if x, y, _, _ := areaOverlay.Get(image.Point{X: 8, Y: 8}); x != uint8(want*4) || y != spoilHeapTileY {
+				t.Fatalf("depth %d stroke %d: spoil heap tile %d,%d, want %d,%d", test.depth, i+1, x, y, want*4, spoilHeapTileY)
+			}
+		}
+	}
+}
+
+// TestClosedGraveTiles checks nothing a closed grave keeps hidden clears
+// away its dirt, which would also let the gravedigger walk over it.
+func TestClosedGraveTiles(t *testing.T) {
+	if err := loadClues(); err != nil {
+		t.Fatal(err)
+	}
+	size := image.Point{X: 16, Y: 16}
+	area, areaOverlay = NewTileMap(size, nowhere{}), NewTileMap(size, nowhere{})
+	g := &Grave{MapX: 4, MapY: 2, DigDepth: 10, dirt: 12, Likes: []string{"They were tall."}}
+	g.generateTraits()
+	g.draw()
+	pos := image.Point{}
+	for pos.Y = 0; pos.Y < 6; pos.Y++ {
+		for pos.X = 0; pos.X < 4; pos.X++ {
+			want := image.Point{X: g.dirt, Y: 4}.Add(pos)
+			if x, y, _, _ := area.Get(pos.Add(image.Point{X: 4, Y: 6})); int(x) != want.X || int(y) != want.Y {
+				t.Fatalf("dirt tile %v is %d,%d, want %d,%d", pos, x, y, want.X, want.Y)
+			}
+		}
+	}
+	if NewWalkGrid(area).Walkable(image.Point{X: 5, Y: 8}) {
+		t.Fatal("the closed grave can be walked over")
+	}
+}
@@ -0,0 +1,308 @@
+// Package headless is an in-memory implementation of cartridge.Console.
+//
+// It keeps sprites and map bank areas as plain data and feeds the cartridge
+// scripted mouse input, so the game can be driven frame by frame from
+// `go test` on a machine with no window or GPU:
+//
+//	c := headless.New()
+//	cartridge.UseConsole(c)
+//	cartridge.Start()
+//	c.Click(image.Point{X: 160, Y: 100})
+//	c.Run(60, cartridge.Update)
+package headless
+
+import (
+	"image"
+	"sort"
+	"strings"
+
+	"github.com/TheMightyGit/losttheplot/cartridge"
+)
+
+// Input is the mouse and keyboard state for a single frame.
+type Input struct {
+	Pos     image.Point
+	Pressed bool
+	Chars   []rune
+}
+
+type Console struct {
+	Sprites  map[int]*Sprite
+	MapBanks map[int]*MapBank
+	ModBanks map[int]*ModBank
+	Frame    int
+
+	script  []Input
+	current Input
+}
+
+func New() *Console {
+	return &Console{
+		Sprites:  map[int]*Sprite{},
+		MapBanks: map[int]*MapBank{},
+		ModBanks: map[int]*ModBank{},
+	}
+}
+
+// Queue appends frames of mouse input, consumed one per Step.
+func (c *Console) Queue(inputs ...Input) {
+	c.script = append(c.script, inputs...)
+}
+
+// Click queues a single frame with the mouse pressed at pos.
+func (c *Console) Click(pos image.Point) {
+	c.Queue(Input{Pos: pos, Pressed: true})
+}
+
+// Type queues a frame per character typed, with the mouse left where it is.
+// Use '\b' for Backspace and '\n' for Enter.
+func (c *Console) Type(txt string) {
+	pos := c.current.Pos
+	if len(c.script) > 0 {
+		pos = c.script[len(c.script)-1].Pos
+	}
+	for _, r := range txt {
+		c.Queue(Input{Pos: pos, Chars: []rune{r}})
+	}
+}
+
+// Pending is the number of queued input frames not yet consumed.
+func (c *Console) Pending() int {
+	return len(c.script)
+}
+
+// Step moves on to the next frame of input. Once the script runs out the
+// mouse stays where it was, unpressed.
+func (c *Console) Step() {
+	c.Frame++
+	if len(c.script) > 0 {
+		c.current = c.script[0]
+		c.script = c.script[1:]
+	} else {
+		c.current.Pressed = false
+		c.current.Chars = nil
+	}
+}
+
+// Run steps the input and calls update n times.
+func (c *Console) Run(n int, update func()) {
+	for i := 0; i < n; i++ {
+		c.Step()
+		update()
+	}
+}
+
+// RunScript steps and updates until the queued input is used up.
+func (c *Console) RunScript(update func()) {
+	for len(c.script) > 0 {
+		c.Step()
+		update()
+	}
+}
+
+func (c *Console) SpritesGet(id int) cartridge.Sprite {
+	s, found := c.Sprites[id]
+	if !found {
+		s = &Sprite{Id: id}
+		c.Sprites[id] = s
+	}
+	return s
+}
+
+// SpritesSort has nothing to do, use SortedSprites to see the draw order.
+func (c *Console) SpritesSort() {}
+
+// SortedSprites returns the visible sprites in draw order.
+func (c *Console) SortedSprites() []*Sprite {
+	sprites := []*Sprite{}
+	for _, s := range c.Sprites {
+		if s.Visible {
+			sprites = append(sprites, s)
+		}
+	}
+	sort.Slice(sprites, func(i, j int) bool {
+		if sprites[i].SortIdx == sprites[j].SortIdx {
+			return sprites[i].Id < sprites[j].Id
+		}
+		return sprites[i].SortIdx < sprites[j].SortIdx
+	})
+	return sprites
+}
+
+func (c *Console) MapBanksGet(id int) cartridge.MapBank {
+	b, found := c.MapBanks[id]
+	if !found {
+		b = &MapBank{}
+		c.MapBanks[id] = b
+	}
+	return b
+}
+
+func (c *Console) ModBanksGet(id int) cartridge.ModBank {
+	b, found := c.ModBanks[id]
+	if !found {
+		b = &ModBank{Position: -1}
+		c.ModBanks[id] = b
+	}
+	return b
+}
+
+func (c *Console) InputMousePressed() bool {
+	return c.current.Pressed
+}
+
+func (c *Console) InputMousePos() image.Point {
+	return c.current.Pos
+}
+
+func (c *Console) InputChars() []rune {
+	return c.current.Chars
+}
+
+type Sprite struct {
+	Id       int
+	Visible  bool
+	GfxBank  int
+	Area     *Area
+	Pos      image.Rectangle
+	Viewport image.Point
+	SortIdx  int
+	Palette  int
+}
+
+func (s *Sprite) Show(gfxBank int, area cartridge.MapBankArea) {
+	s.Visible = true
+	s.GfxBank = gfxBank
+	s.Area, _ = area.(*Area)
+}
+
+func (s *Sprite) Hide() {
+	s.Visible = false
+}
+
+func (s *Sprite) ChangePos(pos image.Rectangle) {
+	s.Pos = pos
+}
+
+func (s *Sprite) ChangeViewport(viewport image.Point) {
+	s.Viewport = viewport
+}
+
+func (s *Sprite) SetSortIdx(idx int) {
+	s.SortIdx = idx
+}
+
+func (s *Sprite) ChangePalette(palette int) {
+	s.Palette = palette
+}
+
+type MapBank struct {
+	Areas []*Area
+}
+
+func (b *MapBank) AllocArea(size image.Point) cartridge.MapBankArea {
+	a := &Area{
+		Size:  size,
+		Cells: make([]Cell, size.X*size.Y),
+	}
+	b.Areas = append(b.Areas, a)
+	return a
+}
+
+// Cell is one map tile. Rune is set when the cell was written by StringToMap.
+type Cell struct {
+	X, Y   uint8
+	Fg, Bg uint8
+	Rune   rune
+}
+
+type Area struct {
+	Size  image.Point
+	Cells []Cell
+}
+
+func (a *Area) in(pos image.Point) bool {
+	return pos.In(image.Rectangle{Max: a.Size})
+}
+
+// Cell returns the cell at pos, or the zero Cell if pos is outside the area.
+func (a *Area) Cell(pos image.Point) Cell {
+	if !a.in(pos) {
+		return Cell{}
+	}
+	return a.Cells[pos.Y*a.Size.X+pos.X]
+}
+
+func (a *Area) Set(pos image.Point, x, y, fg, bg uint8) {
+	if a.in(pos) {
+		a.Cells[pos.Y*a.Size.X+pos.X] = Cell{X: x, Y: y, Fg: fg, Bg: bg}
+	}
+}
+
+func (a *Area) Clear(x, y uint8) {
+	for i := range a.Cells {
+		a.Cells[i] = Cell{X: x, Y: y}
+	}
+}
+
+func (a *Area) StringToMap(pos image.Point, fg, bg uint8, txt string) {
+	start := pos
+	for _, r := range txt {
+		if r == '\n' {
+			pos.X = start.X
+			pos.Y++
+			continue
+		}
+		if a.in(pos) {
+			a.Cells[pos.Y*a.Size.X+pos.X] = Cell{Fg: fg, Bg: bg, Rune: r}
+		}
+		pos.X++
+	}
+}
+
+// Text returns whatever StringToMap has written to the area, one line per
+// row, with everything else as spaces and trailing spaces trimmed.
+func (a *Area) Text() string {
+	lines := make([]string, a.Size.Y)
+	for y := 0; y < a.Size.Y; y++ {
+		line := make([]rune, a.Size.X)
+		for x := 0; x < a.Size.X; x++ {
+			line[x] = ' '
+			if r := a.Cells[y*a.Size.X+x].Rune; r != 0 {
+				line[x] = r
+			}
+		}
+		lines[y] = strings.TrimRight(string(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ModBank records what would have been heard.
+type ModBank struct {
+	Playing  bool
+	Position int
+	Volume   float64
+	Samples  []PlayedSample
+}
+
+type PlayedSample struct {
+	Sample, Note int
+	Volume       float64
+}
+
+func (b *ModBank) PlayFrom(position int) {
+	b.Playing = true
+	b.Position = position
+}
+
+func (b *ModBank) Stop() {
+	b.Playing = false
+}
+
+func (b *ModBank) SetVolume(volume float64) {
+	b.Volume = volume
+}
+
+func (b *ModBank) PlaySample(sample, note int, volume float64) {
+	b.Samples = append(b.Samples, PlayedSample{Sample: sample, Note: note, Volume: volume})
+}
@@ -0,0 +1,72 @@
+package headless_test
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/TheMightyGit/losttheplot/cartridge"
+	"github.com/TheMightyGit/losttheplot/cartridge/headless"
+)
+
+func TestAreaText(t *testing.T) {
+	c := headless.New()
+	a := c.MapBanksGet(0).AllocArea(image.Point{X: 8, Y: 3})
+	a.StringToMap(image.Point{X: 1, Y: 0}, 7, 12, "Hi\nthere")
+	a.Set(image.Point{X: 6, Y: 2}, 3, 4, 0, 0) // a tile, not text
+
+	area := c.MapBanks[0].Areas[0]
+	if text, want := area.Text(), " Hi\n there\n"; text != want {
+		t.Fatalf("text is %q, want %q", text, want)
+	}
+	if cell := area.Cell(image.Point{X: 6, Y: 2}); cell.X != 3 || cell.Y != 4 {
+		t.Fatalf("tile is %d,%d, want 3,4", cell.X, cell.Y)
+	}
+}
+
+func TestScript(t *testing.T) {
+	c := headless.New()
+	c.Click(image.Point{X: 10, Y: 20})
+	c.Type("A1\n")
+	if c.Pending() != 4 {
+		t.Fatalf("%d frames queued, want 4", c.Pending())
+	}
+
+	typed := ""
+	c.Step()
+	if !c.InputMousePressed() || c.InputMousePos() != (image.Point{X: 10, Y: 20}) {
+		t.Fatal("click not on the first frame")
+	}
+	c.RunScript(func() {
+		if c.InputMousePressed() {
+			t.Fatal("still pressed while typing")
+		}
+		typed += string(c.InputChars())
+	})
+	if typed != "A1\n" {
+		t.Fatalf("typed %q", typed)
+	}
+
+	c.Step()
+	if c.InputMousePressed() || len(c.InputChars()) != 0 || c.InputMousePos() != (image.Point{X: 10, Y: 20}) {
+		t.Fatal("input should go idle, with the mouse where it was, once the script runs out")
+	}
+}
+
+func TestTitles(t *testing.T) {
+	cartridge.SetConfigDir(t.TempDir())
+	c := headless.New()
+	cartridge.UseConsole(c)
+	cartridge.Start()
+	c.Run(2, cartridge.Update)
+
+	if text := c.MapBanks[cartridge.MapBankText].Areas[0].Text(); !strings.Contains(text, " New Game") {
+		t.Fatalf("no New Game on the titles:\n%s", text)
+	}
+	if s := c.Sprites[cartridge.SpriteGraveyard]; s == nil || !s.Visible || s.GfxBank != cartridge.GfxBankTitles {
+		t.Fatal("title picture not showing")
+	}
+	if m := c.ModBanks[cartridge.ModBankMusic]; m == nil || !m.Playing {
+		t.Fatal("no title music")
+	}
+}
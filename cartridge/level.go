@@ -0,0 +1,348 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// levelFile is a hand made level, as saved by the editor (or written by
+// hand). The graveyard is Rows of graves, each Columns plots across, found by
+// plot, e.g.
+//
+//	{
+//	  "name": "The Twins",
+//	  "rows": 2,
+//	  "columns": 3,
+//	  "graves": [
+//	    {"plot": "A1", "relation": "evil twin", "likes": ["They died young."], "digDepth": 4}
+//	  ],
+//	  "visitors": [
+//	    {"target": "A1", "ask": "Have you seen my\nbrother? Well, his\ngrave.", "thanks": "That's him."}
+//	  ]
+//	}
+//
+// Any graves it leaves out are made up as usual, but never to fit a
+// visitor's clues, so the only lookalikes are the ones the level puts there.
+type levelFile struct {
+	Name     string         `json:"name,omitempty"`    // shown at the start of the day
+	Rows     int            `json:"rows"`              // 1 to maxLevelRows
+	Columns  int            `json:"columns,omitempty"` // 1 to maxCols, all of them if left out
+	Graves   []levelGrave   `json:"graves"`
+	Visitors []levelVisitor `json:"visitors"`
+}
+
+type levelGrave struct {
+	Plot     string   `json:"plot"`
+	Relation string   `json:"relation"`
+	Likes    []string `json:"likes"`
+	DigDepth int      `json:"digDepth"`
+}
+
+type levelVisitor struct {
+	Target string `json:"target"` // the plot they're looking for
+	visitorDialogue
+}
+
+// visitorDialogue is what a visitor says in place of the usual lines, any
+// left empty are the usual.
+type visitorDialogue struct {
+	Ask    string `json:"ask,omitempty"`    // before their clues, instead of asking where their relation is
+	Thanks string `json:"thanks,omitempty"` // when given the right plot
+	Wrong  string `json:"wrong,omitempty"`  // when given the wrong plot
+}
+
+// the visitor speech box is 38 wide inside, and the clues come after a blank
+// line below what's asked.
+const (
+	dialogueWidth = 38
+	levelNameMax  = 24
+)
+
+const maxVisitors = SpriteVisitor5 - SpriteVisitor1 + 1
+
+// maxLevelRows keeps a typo in a hand made level from asking for a graveyard
+// of millions of rows. Made up ones grow past it.
+const maxLevelRows = 100
+
+var (
+	levelPaths []string          // to load once the clues are
+	levelJSON  []json.RawMessage // played in place of levelPaths, from a replay
+	levels     []*levelFile      // days 1, 2... are played from these in turn
+	packOnly   bool              // the run ends after the last of the levels
+)
+
+// PlayLevel plays the level file as the first day, rather than a made up
+// one. Call it before Start.
+func PlayLevel(path string) {
+	levelPaths = []string{path}
+	levelJSON = nil
+	packOnly = false
+}
+
+// PlayLevelPack plays every level file (*.json) in the directory, in name
+// order (so 01.json, 02.json...), one a day, and the run is over once
+// they've all been played. Call it before Start.
+func PlayLevelPack(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("%s: no level files", dir)
+	}
+	sort.Strings(paths)
+	levelPaths = paths
+	levelJSON = nil
+	packOnly = true
+	return nil
+}
+
+// packComplete is whether the last day of a level pack has been played.
+func packComplete() bool {
+	return packOnly && lvlNum >= len(levels)
+}
+
+// loadLevels reads the files given to PlayLevel, or the levels a replay
+// brought with it.
+func loadLevels() error {
+	levels = nil
+	for i, b := range levelJSON {
+		l, err := parseLevel(b)
+		if err != nil {
+			return fmt.Errorf("replay level %d: %w", i+1, err)
+		}
+		levels = append(levels, l)
+	}
+	for _, path := range levelPaths {
+		l, err := loadLevel(path)
+		if err != nil {
+			return err
+		}
+		levels = append(levels, l)
+	}
+	return nil
+}
+
+// levelFor returns the hand made level for the day, if there is one.
+func levelFor(lvl int) *levelFile {
+	if lvl < 1 || lvl > len(levels) {
+		return nil
+	}
+	return levels[lvl-1]
+}
+
+// levelSources are the contents of the levels being played, as a replay
+// records them.
+func levelSources() ([]json.RawMessage, error) {
+	sources := append([]json.RawMessage{}, levelJSON...)
+	for _, path := range levelPaths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, b)
+	}
+	return sources, nil
+}
+
+func loadLevel(path string) (*levelFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l, err := parseLevel(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return l, nil
+}
+
+func parseLevel(b []byte) (*levelFile, error) {
+	l := &levelFile{}
+	if err := json.Unmarshal(b, l); err != nil {
+		return nil, err
+	}
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *levelFile) save(path string) error {
+	b, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// plots are the plot references a graveyard this size has.
+func plots(rows, cols int) map[string]bool {
+	plots := map[string]bool{}
+	for row := 0; row < rows; row++ {
+		for _, h := range horizGridRef[:cols] {
+			plots[verticalGridRef(row)+h] = true
+		}
+	}
+	return plots
+}
+
+// columns is Columns, with all of them if it's left out.
+func (l *levelFile) columns() int {
+	if l.Columns == 0 {
+		return maxCols
+	}
+	return l.Columns
+}
+
+// checkText makes sure the text fits in the lines of a speech box.
+func checkText(txt string, lines int) error {
+	if n := strings.Count(txt, "\n") + 1; n > lines {
+		return fmt.Errorf("%d lines, only %d fit", n, lines)
+	}
+	for _, line := range strings.Split(txt, "\n") {
+		if len(line) > dialogueWidth {
+			return fmt.Errorf("%q is more than %d wide", line, dialogueWidth)
+		}
+	}
+	return nil
+}
+
+func (l *levelFile) validate() error {
+	if len(l.Name) > levelNameMax {
+		return fmt.Errorf("name is more than %d long", levelNameMax)
+	}
+	if l.Rows < 1 || l.Rows > maxLevelRows {
+		return fmt.Errorf("rows must be 1 to %d, got %d", maxLevelRows, l.Rows)
+	}
+	if l.Columns < 0 || l.Columns > maxCols {
+		return fmt.Errorf("columns must be 1 to %d, got %d", maxCols, l.Columns)
+	}
+	valid := plots(l.Rows, l.columns())
+	seen := map[string]bool{}
+	likes := map[string][]string{}
+	for _, g := range l.Graves {
+		if !valid[g.Plot] {
+			return fmt.Errorf("no plot %q in %d rows of %d", g.Plot, l.Rows, l.columns())
+		}
+		likes[g.Plot] = g.Likes
+		if seen[g.Plot] {
+			return fmt.Errorf("plot %s is in twice", g.Plot)
+		}
+		seen[g.Plot] = true
+		if g.Relation == "" {
+			return fmt.Errorf("plot %s has no relation", g.Plot)
+		}
+		if g.DigDepth < 1 || g.DigDepth > maxDigDepth {
+			return fmt.Errorf("plot %s dig depth must be 1 to %d, got %d", g.Plot, maxDigDepth, g.DigDepth)
+		}
+		if len(g.Likes) > maxClues {
+			return fmt.Errorf("plot %s has %d likes, a visitor can only say %d", g.Plot, len(g.Likes), maxClues)
+		}
+		cats := map[int]bool{}
+		for _, like := range g.Likes {
+			cat := likeCategory(like)
+			if cat < 0 {
+				return fmt.Errorf("plot %s like %q isn't in %s", g.Plot, like, cluesFile)
+			}
+			if cats[cat] {
+				return fmt.Errorf("plot %s has two %s likes", g.Plot, TraitCategories[cat].Name)
+			}
+			cats[cat] = true
+		}
+	}
+	if len(l.Visitors) == 0 || len(l.Visitors) > maxVisitors {
+		return fmt.Errorf("needs 1 to %d visitors, got %d", maxVisitors, len(l.Visitors))
+	}
+	for i, v := range l.Visitors {
+		if !valid[v.Target] {
+			return fmt.Errorf("visitor %d is looking for plot %q, which isn't in %d rows of %d", i+1, v.Target, l.Rows, l.columns())
+		}
+		if v.Ask != "" {
+			// made up graves have as many likes as the difficulty gives.
+			clues := maxClues
+			if l, found := likes[v.Target]; found {
+				clues = len(l)
+			}
+			if err := checkText(v.Ask, speechMaxLines-1-clues); err != nil {
+				return fmt.Errorf("visitor %d ask: %w", i+1, err)
+			}
+		}
+		if err := checkText(v.Thanks, speechMaxLines); err != nil {
+			return fmt.Errorf("visitor %d thanks: %w", i+1, err)
+		}
+		if err := checkText(v.Wrong, speechMaxLines); err != nil {
+			return fmt.Errorf("visitor %d wrong: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// setupGraveyard lays out the graveyard, then makes the level's graves how
+// it says. It returns the graves by plot.
+func (l *levelFile) setupGraveyard() map[string]*Grave {
+	graveyard = &Graveyard{}
+	graveyard.Setup(l.Rows, l.columns())
+	byPlot := map[string]*Grave{}
+	for _, g := range graveyard.graves {
+		byPlot[g.Plot()] = g
+	}
+	for _, lg := range l.Graves {
+		g := byPlot[lg.Plot]
+		g.Relation = lg.Relation
+		g.Likes = append([]string{}, lg.Likes...)
+		g.DigDepth = lg.DigDepth
+		g.generateTraits()
+		g.drawClosedGrave()
+	}
+	return byPlot
+}
+
+// setup is setupGraveyard and the visitors, for playing the level.
+func (l *levelFile) setup() {
+	byPlot := l.setupGraveyard()
+	NPCId = SpriteVisitor1
+	visitors = &Visitors{}
+	for _, lv := range l.Visitors {
+		v := NewVisitor(byPlot[lv.Target])
+		v.dialogue = lv.visitorDialogue
+		visitors.Visitors = append(visitors.Visitors, v)
+	}
+	designed := map[*Grave]bool{}
+	for _, lg := range l.Graves {
+		designed[byPlot[lg.Plot]] = true
+	}
+	visitors.regenerateLookalikes(designed)
+	hideSpareVisitors()
+}
+
+// newLevelFile is the graveyard as it stands, for saving, without any
+// visitors.
+func newLevelFile(g *Graveyard) *levelFile {
+	l := &levelFile{Rows: g.rows, Columns: g.cols}
+	for _, grave := range g.graves {
+		l.Graves = append(l.Graves, levelGrave{
+			Plot:     grave.Plot(),
+			Relation: grave.Relation,
+			Likes:    grave.Likes,
+			DigDepth: grave.DigDepth,
+		})
+	}
+	return l
+}
+
+// setupLevel lays out the graveyard and visitors for lvlNum, from its level
+// file if it has one.
+func setupLevel() {
+	if l := levelFor(lvlNum); l != nil {
+		l.setup()
+		return
+	}
+	graveyard = &Graveyard{} // we need global refs to this before it sets up, hence the two set
+	graveyard.Setup(graveRows(lvlNum), maxCols)
+	visitors = NewVisitors(difficulty.Visitors)
+}
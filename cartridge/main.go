@@ -4,13 +4,10 @@ import (
 	"embed"
 	"fmt"
 	"image"
+	"log"
 	"math"
 	"math/rand"
 	"strings"
-	"time"
-
-	"github.com/TheMightyGit/marv/marvlib"
-	"github.com/TheMightyGit/marv/marvtypes"
 )
 
 //go:embed "resources/*"
@@ -21,6 +18,10 @@ const (
 	MODE_TITLE_SCREEN
 	MODE_GAME_SETUP
 	MODE_GAME
+	MODE_GAME_OVER_SETUP
+	MODE_GAME_OVER
+	MODE_EDITOR_SETUP
+	MODE_EDITOR
 )
 
 const (
@@ -60,43 +61,84 @@ type Camera struct {
 
 	TargetX float64
 	TargetY float64
+
+	// Smoothing is the fraction of the remaining distance covered each frame.
+	Smoothing float64
+	// SnapDistance is how close (in pixels) is close enough to just jump there.
+	SnapDistance float64
+	// DeadZone is how far (in pixels) the target can wander before the camera
+	// bothers to follow it.
+	DeadZone float64
+
+	moving bool
+	shakeX float64
+	shakeY float64
+	shake  *Task
+}
+
+func NewCamera() *Camera {
+	return &Camera{
+		Smoothing:    0.12,
+		SnapDistance: 0.5,
+		DeadZone:     8,
+	}
 }
 
 func (c *Camera) GetAsPoint() image.Point {
-	return image.Point{X: int(c.X), Y: int(c.Y)}
+	return image.Point{X: int(c.X + c.shakeX), Y: int(c.Y + c.shakeY)}
 }
 
 func (c *Camera) Shake() {
-	go func() {
-		for i := 0; i < 10; i++ {
-			time.Sleep(16 * time.Millisecond)
-			c.X += (rand.Float64() - 0.5) * 5
-			c.Y += (rand.Float64() - 0.5) * 5
-		}
-	}()
+	if c.shake.Active() {
+		c.shake.Cancel()
+	}
+	c.shake = scheduler.Tween(10, func(float64) {
+		c.shakeX += (rnd.Float64() - 0.5) * 5
+		c.shakeY += (rnd.Float64() - 0.5) * 5
+	}).Then(func() {
+		c.shakeX, c.shakeY = 0, 0
+	})
+}
+
+// LookAt sets the point (in graveyard pixels) to centre the screen on.
+func (c *Camera) LookAt(x, y float64) {
+	c.TargetX = x
+	c.TargetY = y
+}
+
+// Frame centres the screen on a hitbox, e.g. a grave or visitor.
+func (c *Camera) Frame(hb Hitbox) {
+	centre := hb.Min.Add(hb.Max).Div(2)
+	c.LookAt(float64(centre.X), float64(centre.Y))
+}
+
+// clamp keeps the top left of the screen within the graveyard.
+func (c *Camera) clamp(x, y float64) (float64, float64) {
+	size := graveyard.Size()
+	maxX := math.Max(0, float64(size.X-fullScreenRect.Dx()))
+	maxY := math.Max(0, float64(size.Y-fullScreenRect.Dy()))
+	return math.Max(0, math.Min(x, maxX)), math.Max(0, math.Min(y, maxY))
 }
 
 func (c *Camera) Update() {
-	if c.X+160 < c.TargetX {
-		if c.X < float64((graveyard.w*4*8)-320) {
-			c.X += 1
-		}
-	}
-	if c.X+160 > c.TargetX {
-		if c.X > 1 {
-			c.X -= 1
-		}
-	}
-	if c.Y+100 < c.TargetY {
-		if c.Y < float64((graveyard.h*4*8)-200) {
-			c.Y += 1
-		}
+	goalX, goalY := c.clamp(
+		c.TargetX-float64(fullScreenRect.Dx()/2),
+		c.TargetY-float64(fullScreenRect.Dy()/2),
+	)
+	dx, dy := goalX-c.X, goalY-c.Y
+
+	if !c.moving && math.Abs(dx) <= c.DeadZone && math.Abs(dy) <= c.DeadZone {
+		return
 	}
-	if c.Y+100 > c.TargetY {
-		if c.Y > 1 {
-			c.Y -= 1
-		}
+	c.moving = true
+
+	if math.Hypot(dx, dy) <= c.SnapDistance {
+		c.X, c.Y = goalX, goalY
+		c.moving = false
+		return
 	}
+	c.X += dx * c.Smoothing
+	c.Y += dy * c.Smoothing
 }
 
 type Player struct {
@@ -107,9 +149,14 @@ type Player struct {
 	h     int
 	tx    float64
 	ty    float64
+	path  []image.Point
 
 	animFrame int
 
+	digTarget *Grave // walking over to dig this
+	digAction *Task
+	stamina   int // dig strokes left in them today
+
 	flagTalkedToVisitors bool
 	flagDoneSomeDigging  bool
 }
@@ -121,9 +168,11 @@ func NewPlayer() *Player {
 		Y:     128,
 		w:     32,
 		h:     64,
+
+		stamina: difficulty.Stamina,
 	}
 
-	marvlib.API.SpritesGet(SpritePlayer).Show(GfxBankPeople, areaPeople)
+	console.SpritesGet(SpritePlayer).Show(GfxBankPeople, areaPeople)
 
 	return p
 }
@@ -131,7 +180,7 @@ func NewPlayer() *Player {
 func (p *Player) Update() {
 
 	if text.plotInput {
-		if text.selectedHoriz != "" && text.selectedVertical != "" {
+		if text.plotConfirmed {
 			// fmt.Println("PLOT INPUT!!")
 			text.PlayerSay("") // NOTE: blank needed to stop plot input.
 			text.PlayerSay("They're 100% in " + text.selectedVertical + text.selectedHoriz + ".\nNo doubt. I'm almost certain\nthat I'm probably right.")
@@ -141,31 +190,42 @@ func (p *Player) Update() {
 		}
 	}
 
-	if marvlib.API.InputMousePressed() {
-		clickPoint := marvlib.API.InputMousePos()
+	if console.InputMousePressed() {
+		clickPoint := console.InputMousePos()
 
-		if text.plotInput && text.Hitbox.IsHitNoCameraOffset(clickPoint) {
+		if text.notebookOpen || text.notebookButton().IsHitNoCameraOffset(clickPoint) {
+			// the notebook is in the way.
+		} else if text.plotInput && text.Hitbox.IsHitNoCameraOffset(clickPoint) {
 			// let text component handle the input itself.
 		} else if grave := graveyard.GetClickedGrave(clickPoint); grave != nil {
 			if !p.flagTalkedToVisitors {
 				text.PlayerSay("I should talk to the visitors\nbefore I get digging!")
-			} else {
-				p.flagDoneSomeDigging = true
+			} else if p.stamina < grave.staminaCost() {
+				text.PlayerSay("I'm dead on my feet.\nNo more spade work for me\ntoday.")
+			} else if grave != p.digTarget {
+				p.cancelDig()
 				// fmt.Println(grave)
-				grave.Dig()
-				// move the grave (vertically) into visible space.
-				camera.TargetY = camera.Y + float64(clickPoint.Y)
+				p.digTarget = grave
+				foot := grave.Foot()
+				if !p.WalkTo(float64(foot.X), float64(foot.Y-p.footOffset())) {
+					// don't dig it from over here.
+					p.digTarget = nil
+					text.PlayerSay("I can't find a way over to " + grave.GridY + grave.GridX + "!")
+				}
+				// move the grave into visible space.
+				camera.Frame(grave.Hitbox)
 			}
 		} else if visitor := visitors.GetClickedVisitor(clickPoint); visitor != nil {
+			p.cancelDig()
 			p.flagTalkedToVisitors = true
+			visitor.talkedTo = true
 			// fmt.Println(visitor)
 			// fmt.Println(visitor.Grave)
 			text.VisitorSay(
-				"Where is my...\n ..."+visitor.Grave.Relation+"?\n\n"+strings.Join(visitor.Grave.Likes, "\n"),
-				fmt.Sprintf("Visitor %d", (visitor.SpriteId-SpriteVisitor1)+1),
+				visitor.ask()+"\n\n"+strings.Join(visitor.Grave.Likes, "\n"),
+				fmt.Sprintf("Visitor %d (%s)", (visitor.SpriteId-SpriteVisitor1)+1, visitor.mood()),
 			)
-			camera.TargetX = float64(visitor.Pos.X + 16)
-			camera.TargetY = float64(visitor.Pos.Y - 64)
+			camera.Frame(visitor.Hitbox)
 			visitors.currentVisitor = visitor
 			if p.flagDoneSomeDigging {
 				// show grid ref UI
@@ -187,72 +247,185 @@ func (p *Player) Update() {
 
 			// marvlib.API.ConsolePrintln(p.tx, p.ty)
 
-			camera.TargetX = float64(p.tx)
-			camera.TargetY = float64(p.ty)
+			p.cancelDig()
+			p.WalkTo(p.tx, p.ty)
+			camera.LookAt(p.tx, p.ty)
 			text.VisitorSay("", "")
 			text.PlayerSay("")
 		}
 
 	}
 
-	if p.tx != 0 && p.ty != 0 {
-
-		// are we already wihin p.Speed of the target?
-		if math.Abs(p.X-p.tx) > p.Speed {
-			// move towards target click
-			if p.X < p.tx {
-				p.X += p.Speed
-				p.animFrame++
-			} else if p.X > p.tx {
-				p.X -= p.Speed
-				p.animFrame++
-			}
-		}
-
-		if math.Abs(p.Y-p.ty) > p.Speed {
-			if p.Y < p.ty {
-				p.Y += p.Speed
-				p.animFrame++
-			} else if p.Y > p.ty {
-				p.Y -= p.Speed
-				p.animFrame++
-			}
+	if len(p.path) > 0 {
+		// follow the path, one waypoint at a time.
+		next := p.path[0]
+		dx := float64(next.X) - p.X
+		dy := float64(next.Y-p.footOffset()) - p.Y
+		dist := math.Hypot(dx, dy)
+		if dist <= p.Speed {
+			p.X, p.Y = float64(next.X), float64(next.Y-p.footOffset())
+			p.path = p.path[1:]
+		} else {
+			p.X += dx / dist * p.Speed
+			p.Y += dy / dist * p.Speed
 		}
+		p.animFrame = (p.animFrame + 1) % 20
+	} else if p.digTarget != nil && !p.digAction.Active() {
+		p.startDig()
+	}
 
-		p.animFrame = p.animFrame % 20
+	viewport := image.Point{X: 32 * (p.animFrame / 10)}
+	if p.digAction.Active() {
+		// the spade raised then struck, under the walking frames in
+		// resources/2_8x8.png.
+		viewport.Y = 64
 	}
 
 	cp := camera.GetAsPoint()
 	pos := image.Point{X: int(p.X) - (p.w / 2) - cp.X, Y: int(p.Y) - (p.h / 2) - cp.Y}
-	marvlib.API.SpritesGet(SpritePlayer).ChangePos(image.Rectangle{
+	console.SpritesGet(SpritePlayer).ChangePos(image.Rectangle{
 		Min: pos,
 		Max: image.Point{X: p.w, Y: p.h},
 	})
-	marvlib.API.SpritesGet(SpritePlayer).ChangeViewport(image.Point{X: 32 * (p.animFrame / 10)})
+	console.SpritesGet(SpritePlayer).ChangeViewport(viewport)
 
 	ySortPos := pos.Y + cp.Y
 	if ySortPos < 1 {
 		ySortPos = 1
 	}
-	marvlib.API.SpritesGet(SpritePlayer).SetSortIdx(ySortPos)
-	marvlib.API.SpritesSort()
+	console.SpritesGet(SpritePlayer).SetSortIdx(ySortPos)
+	console.SpritesSort()
+}
+
+const digActionFrames = 20
+
+const (
+	staminaPerDay     = 100 // on Normal, see Difficulty
+	digStaminaCost    = 1
+	refillStaminaCost = 3
+)
+
+// staminaCost is what the next swing of the spade at the grave takes out of
+// the player, filling an opened grave back in is harder work than digging.
+// One a visitor's looking for is left open, so it's no work at all.
+func (g *Grave) staminaCost() int {
+	if g.clickcounter >= g.DigDepth {
+		if g.wanted() {
+			return 0
+		}
+		return refillStaminaCost
+	}
+	return digStaminaCost
+}
+
+// startDig swings the spade at the grave we've walked to, and digs it (or
+// fills it back in if it's open) once the swing lands.
+func (p *Player) startDig() {
+	grave := p.digTarget
+	p.animFrame = 0
+	p.digAction = scheduler.Tween(digActionFrames, func(progress float64) {
+		p.animFrame = int(progress*20) % 20
+	}).Then(func() {
+		p.digTarget = nil
+		p.animFrame = 0
+		p.flagDoneSomeDigging = true
+		p.stamina -= grave.staminaCost()
+		if grave.clickcounter >= grave.DigDepth && !grave.wanted() {
+			grave.Refill()
+		} else {
+			grave.Dig()
+		}
+		text.HUD()
+		camera.Frame(grave.Hitbox)
+	})
+}
+
+// cancelDig stops walking over to, or swinging at, a grave.
+func (p *Player) cancelDig() {
+	if p.digAction.Active() {
+		p.digAction.Cancel()
+		p.animFrame = 0
+	}
+	p.digTarget = nil
+	p.path = nil
+}
+
+// footOffset is how far below the player's position their feet are, which is
+// what has to stay on the paths.
+func (p *Player) footOffset() int {
+	return (p.h / 2) - 8
+}
+
+// WalkTo sets off towards the target (where the player's X, Y should end up),
+// following the paths between plots. It returns false if there's no way
+// there.
+func (p *Player) WalkTo(tx, ty float64) bool {
+	p.tx, p.ty = tx, ty
+	from := image.Point{X: int(p.X), Y: int(p.Y) + p.footOffset()}
+	to := image.Point{X: int(tx), Y: int(ty) + p.footOffset()}
+	p.path = graveyard.walkGrid.FindPath(from, to)
+	return p.path != nil
 }
 
 type Graveyard struct {
-	w      int
-	h      int
-	graves []*Grave
+	w        int
+	h        int
+	rows     int
+	cols     int // plots across each row
+	graves   []*Grave
+	walkGrid *WalkGrid
 }
 
-func (g *Graveyard) Setup(numRowsOfGraves int) {
-	g.w = 16 // Fixed, or it'll overflow onto the overlay and underlay parts of the map.
-	g.h = 4 + (numRowsOfGraves * 4)
+const graveyardMapWidth = 64
+
+// graveRows is how many rows of graves a level gets. The graveyard grows a
+// row a day, for as long as the run lasts.
+func graveRows(lvl int) int {
+	return lvl
+}
+
+func (g *Graveyard) Setup(numRowsOfGraves, numCols int) {
+	g.setSize(numRowsOfGraves, numCols)
 
 	g.clearOverlay()
 	g.drawWalls()
 	g.drawGraves()
 	g.drawGrass()
 
+	g.show()
+}
+
+// Restore lays out the graveyard around graves that already exist (e.g. from
+// a save) rather than generating new ones.
+func (g *Graveyard) Restore(numRowsOfGraves, numCols int, graves []*Grave) {
+	g.setSize(numRowsOfGraves, numCols)
+
+	g.clearOverlay()
+	g.drawWalls()
+	g.graves = graves
+	for _, grave := range g.graves {
+		grave.draw()
+	}
+	g.drawGrass()
+
+	g.show()
+}
+
+func (g *Graveyard) setSize(numRowsOfGraves, numCols int) {
+	g.rows = numRowsOfGraves
+	g.cols = numCols
+	g.w = graveyardMapWidth / 4
+	g.h = 4 + (numRowsOfGraves * 4)
+
+	size := image.Point{X: g.w * 4, Y: g.h * 4}
+	areaUnderlay = NewTileMap(size, windowUnderlay)
+	area = NewTileMap(size, window)
+	areaOverlay = NewTileMap(size, windowOverlay)
+}
+
+func (g *Graveyard) show() {
+	g.walkGrid = NewWalkGrid(area)
+
 	// splat mouse pointer somewhere we can use it
 	pos := image.Point{}
 	for pos.Y = 0; pos.Y < 4; pos.Y++ {
@@ -261,21 +434,77 @@ func (g *Graveyard) Setup(numRowsOfGraves int) {
 		}
 	}
 
-	marvlib.API.SpritesGet(SpriteGraveyard).ChangePos(fullScreenRect)
-	marvlib.API.SpritesGet(SpriteGraveyard).Show(GfxBankGraveyard, area)
+	console.SpritesGet(SpriteGraveyard).ChangePos(fullScreenRect)
+	console.SpritesGet(SpriteGraveyard).Show(GfxBankGraveyard, window)
+
+	console.SpritesGet(SpriteGraveyardUnderlay).ChangePos(fullScreenRect)
+	console.SpritesGet(SpriteGraveyardUnderlay).Show(GfxBankGraveyard, windowUnderlay)
 
-	marvlib.API.SpritesGet(SpriteGraveyardUnderlay).ChangePos(fullScreenRect)
-	marvlib.API.SpritesGet(SpriteGraveyardUnderlay).Show(GfxBankGraveyard, areaUnderlay)
+	console.SpritesGet(SpriteGraveyardOverlay).ChangePos(fullScreenRect)
+	console.SpritesGet(SpriteGraveyardOverlay).Show(GfxBankGraveyard, windowOverlay)
 
-	marvlib.API.SpritesGet(SpriteGraveyardOverlay).ChangePos(fullScreenRect)
-	marvlib.API.SpritesGet(SpriteGraveyardOverlay).Show(GfxBankGraveyard, areaOverlay)
+	g.scroll()
+}
+
+// scroll shows the part of each layer under the camera.
+func (g *Graveyard) scroll() {
+	cp := camera.GetAsPoint()
+	console.SpritesGet(SpriteGraveyardUnderlay).ChangeViewport(areaUnderlay.show(cp))
+	console.SpritesGet(SpriteGraveyard).ChangeViewport(area.show(cp))
+	console.SpritesGet(SpriteGraveyardOverlay).ChangeViewport(areaOverlay.show(cp))
 }
 
 var (
-	horizGridRef    = []string{"1", "2", "3", "4", "5"}
-	verticalGridRef = []string{"A", "B", "C", "D", "E", "F", "G", "H", "I"}
+	horizGridRef = []string{"1", "2", "3", "4", "5"}
+	maxCols      = len(horizGridRef)
 )
 
+// verticalGridRef labels rows of graves like spreadsheet columns: A to Z,
+// then AA, AB and so on.
+func verticalGridRef(row int) string {
+	ref := ""
+	for row++; row > 0; row = (row - 1) / 26 {
+		ref = string(rune('A'+(row-1)%26)) + ref
+	}
+	return ref
+}
+
+func (g *Graveyard) colRefs() []string {
+	return horizGridRef[:g.cols]
+}
+
+func (g *Graveyard) rowRefs() []string {
+	refs := []string{}
+	for row := 0; row < g.rows; row++ {
+		refs = append(refs, verticalGridRef(row))
+	}
+	return refs
+}
+
+// rowRef returns ref if it's one of the graveyard's rows, otherwise "".
+func (g *Graveyard) rowRef(ref string) string {
+	for _, r := range g.rowRefs() {
+		if r == ref {
+			return r
+		}
+	}
+	return ""
+}
+
+func (g *Graveyard) hasRowPrefix(prefix string) bool {
+	for _, r := range g.rowRefs() {
+		if strings.HasPrefix(r, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Size is the graveyard's extent in pixels.
+func (g *Graveyard) Size() image.Point {
+	return image.Point{X: g.w * 4 * 8, Y: g.h * 4 * 8}
+}
+
 func (g *Graveyard) GetClickedGrave(screenpoint image.Point) *Grave {
 	for _, grave := range g.graves {
 		if grave.Hitbox.IsHit(screenpoint) {
@@ -286,8 +515,8 @@ func (g *Graveyard) GetClickedGrave(screenpoint image.Point) *Grave {
 }
 
 func (g *Graveyard) drawGrass() {
-	for y := 0; y < 256; y += 4 {
-		for x := 0; x < 64; x += 4 {
+	for y := 0; y < g.h*4; y += 4 {
+		for x := 0; x < g.w*4; x += 4 {
 			g.drawCommon(areaUnderlay, x, y, 24, 24, 4, 4)
 		}
 	}
@@ -301,8 +530,8 @@ func (g *Graveyard) drawGraves() {
 	gX := 0
 	gY := 0
 	for y := 16; y < ((g.h - 3) * 4); y += 16 {
-		for x := 8; x < ((g.w - 1) * 4); x += 11 {
-			grave := NewGrave(x, y, horizGridRef[gX], verticalGridRef[gY])
+		for x := 8; x < ((g.w-1)*4) && gX < g.cols; x += 11 {
+			grave := NewGrave(x, y, horizGridRef[gX], verticalGridRef(gY))
 			// fmt.Println(grave)
 			g.graves = append(g.graves, grave)
 
@@ -321,7 +550,9 @@ func (g *Graveyard) drawWalls() {
 	for y := 0; y < g.h; y++ {
 		for x := 0; x < g.w; x++ {
 			if y == 0 {
-				if x == 0 {
+				if x == gateBlock {
+					// leave a gap for the gate
+				} else if x == 0 {
 					g.drawTopLeft(x*4, y*4)
 				} else if x == (g.w - 1) {
 					g.drawTopRight(x*4, y*4)
@@ -347,7 +578,7 @@ func (g *Graveyard) drawWalls() {
 	}
 }
 
-func (g *Graveyard) drawCommon(area marvtypes.MapBankArea, dstX int, dstY int, srcX int, srcY int, w int, h int) {
+func (g *Graveyard) drawCommon(area *TileMap, dstX int, dstY int, srcX int, srcY int, w int, h int) {
 	offset := image.Point{X: dstX, Y: dstY}
 	pos := image.Point{}
 	for pos.Y = 0; pos.Y < h; pos.Y++ {
@@ -389,18 +620,73 @@ func (g *Graveyard) drawBottomRight(x, y int) {
 	g.drawCommon(area, x, y, 28, 28, 4, 4)
 }
 
+// opened graves nobody's looking for upset the neighbours until they're
+// filled back in, costing this much reputation (on Normal, see Difficulty)
+// each every so often.
+const (
+	openGravePenalty       = 2
+	openGravePenaltyFrames = 10 * framesPerSecond
+)
+
+// wronglyOpened are the opened graves that aren't any visitor's target.
+func (g *Graveyard) wronglyOpened() []*Grave {
+	opened := []*Grave{}
+	for _, grave := range g.graves {
+		if grave.clickcounter >= grave.DigDepth && !grave.wanted() {
+			opened = append(opened, grave)
+		}
+	}
+	return opened
+}
+
 func (g *Graveyard) Update() {
-	cp := camera.GetAsPoint()
-	marvlib.API.SpritesGet(SpriteGraveyardUnderlay).ChangeViewport(cp)
-	marvlib.API.SpritesGet(SpriteGraveyard).ChangeViewport(cp)
-	marvlib.API.SpritesGet(SpriteGraveyardOverlay).ChangeViewport(cp)
+	if !clock.Over() && clock.Frame > 0 && clock.Frame%openGravePenaltyFrames == 0 {
+		if opened := g.wronglyOpened(); len(opened) > 0 {
+			changeReputation(-difficulty.OpenGraveCost * len(opened))
+		}
+	}
+
+	g.scroll()
 }
 
 type Text struct {
 	Hitbox           Hitbox
+	continueHitbox   Hitbox
 	plotInput        bool
+	plotConfirmed    bool
 	selectedHoriz    string
 	selectedVertical string
+	typedVertical    string
+	rowPage          int // which of the row picker's pages is showing
+	visitorBoxH      int // rows the visitor's speech box took up
+
+	notebookOpen   bool
+	notebookPage   int
+	notebookScroll int // first line shown
+
+	// what's being said, so it can be put back after the notebook.
+	visitorSaid string
+	visitorName string
+	playerSaid  string
+
+	tooltipGrave *Grave
+	tooltipAt    image.Point // text cell the pointer was in
+	tooltipRect  image.Rectangle
+}
+
+// notebookButton is where to click to open and close the notebook, on the
+// HUD or on the notebook itself.
+func (t *Text) notebookButton() Hitbox {
+	y := 4
+	if t.notebookOpen {
+		y = 0
+	}
+	return Hitbox{
+		Rectangle: image.Rectangle{
+			Min: image.Point{X: 41 * 6, Y: y * 8},
+			Max: image.Point{X: 54 * 6, Y: (y + 1) * 8},
+		},
+	}
 }
 
 func NewText() *Text {
@@ -418,12 +704,12 @@ func NewText() *Text {
 	t.Clear(0, 0, 54, 25)
 	// t.PlayerSay("DIGGING IT")
 
-	marvlib.API.SpritesGet(SpriteText).ChangePos(fullScreenRect)
-	marvlib.API.SpritesGet(SpriteText).Show(GfxBankFont, areaText)
-	marvlib.API.SpritesGet(SpriteText).SetSortIdx(88888)
+	console.SpritesGet(SpriteText).ChangePos(fullScreenRect)
+	console.SpritesGet(SpriteText).Show(GfxBankFont, areaText)
+	console.SpritesGet(SpriteText).SetSortIdx(88888)
 
-	marvlib.API.SpritesGet(SpriteMousePointer).Show(GfxBankGraveyard, areaMousePointer)
-	marvlib.API.SpritesGet(SpriteMousePointer).SetSortIdx(99999)
+	console.SpritesGet(SpriteMousePointer).Show(GfxBankGraveyard, areaMousePointer)
+	console.SpritesGet(SpriteMousePointer).SetSortIdx(99999)
 
 	return t
 }
@@ -439,14 +725,35 @@ func (t *Text) Clear(sx, sy, w, h int) {
 
 func (t *Text) EnablePlotInput() {
 	t.plotInput = true
+	t.plotConfirmed = false
 	t.selectedHoriz = ""
 	t.selectedVertical = ""
+	t.typedVertical = ""
+	t.rowPage = 0
+}
+
+// redraw puts back what's being said and the HUD, after something drawn
+// over them has gone.
+func (t *Text) redraw() {
+	if t.visitorSaid != "" {
+		t.VisitorSay(t.visitorSaid, t.visitorName)
+	}
+	if t.playerSaid != "" {
+		t.PlayerSay(t.playerSaid)
+	}
+	t.HUD()
 }
 
 func (t *Text) PlayerSay(txt string) {
+	t.playerSaid = txt
 	if txt == "" {
-		t.Clear(0, 20, 54, 5)
 		t.plotInput = false
+	}
+	if t.notebookOpen {
+		return
+	}
+	if txt == "" {
+		t.Clear(0, 20, 54, 5)
 	} else {
 		t.SpeechBox(13, 20, 40, 5, 12)
 		areaText.StringToMap(image.Point{X: 1 + 13, Y: 21}, 10, 7, txt)
@@ -454,15 +761,79 @@ func (t *Text) PlayerSay(txt string) {
 	}
 }
 
+// the visitor's speech box holds speechLines, growing to fit up to
+// speechMaxLines (a two line question, a blank line and a clue from each row
+// of Likes).
+const (
+	speechLines    = 6
+	speechMaxLines = 7
+)
+
 func (t *Text) VisitorSay(txt string, visitorName string) {
+	t.visitorSaid, t.visitorName = txt, visitorName
+	if t.notebookOpen {
+		return
+	}
+	if t.visitorBoxH > 0 {
+		t.Clear(0, 0, 40, t.visitorBoxH)
+		t.visitorBoxH = 0
+	}
 	if txt == "" {
-		t.Clear(0, 0, 40, 8)
-	} else {
-		t.SpeechBox(0, 0, 40, 8, 12)
-		areaText.StringToMap(image.Point{X: 1, Y: 1}, 10, 7, txt)
-		areaText.StringToMap(image.Point{X: 36 - len(visitorName), Y: 0}, 7, 12, " "+visitorName+" ")
-		if strings.HasPrefix(visitorName, "Visitor") {
-			areaText.Set(image.Point{X: 23, Y: 7}, 18, 2, 12, 16)
+		return
+	}
+	lines := strings.Count(txt, "\n") + 1
+	if lines < speechLines {
+		lines = speechLines
+	}
+	if lines > speechMaxLines {
+		lines = speechMaxLines
+	}
+	t.visitorBoxH = lines + 2
+	t.SpeechBox(0, 0, 40, t.visitorBoxH, 12)
+	areaText.StringToMap(image.Point{X: 1, Y: 1}, 10, 7, txt)
+	areaText.StringToMap(image.Point{X: 36 - len(visitorName), Y: 0}, 7, 12, " "+visitorName+" ")
+	if strings.HasPrefix(visitorName, "Visitor") {
+		areaText.Set(image.Point{X: 23, Y: t.visitorBoxH - 1}, 18, 2, 12, 16)
+	}
+}
+
+// HUD shows the score, reputation and stamina meters, time of day and the
+// notebook button in the top right corner.
+func (t *Text) HUD() {
+	if t.notebookOpen {
+		return
+	}
+	areaText.StringToMap(image.Point{X: 41, Y: 0}, 7, 12, fmt.Sprintf(" Score %5d ", score))
+	areaText.StringToMap(image.Point{X: 41, Y: 1}, 7, 12, " Rep "+meter(reputation, reputationMax))
+	areaText.StringToMap(image.Point{X: 41, Y: 2}, 7, 12, " Pep "+meter(player.stamina, difficulty.Stamina))
+	areaText.StringToMap(image.Point{X: 41, Y: 3}, 7, 12, " Time  "+clock.String()+" ")
+	areaText.StringToMap(image.Point{X: 41, Y: 4}, 7, 12, " Notebook [N]")
+	areaText.StringToMap(image.Point{X: 41, Y: 5}, 10, 16, fmt.Sprintf(" Mode %-6s ", difficulty.Name))
+}
+
+// meter is an 8 segment bar, never looking empty until it is.
+func meter(value, max int) string {
+	segments := 0
+	if value > 0 {
+		segments = 1 + ((value - 1) * 8 / max)
+	}
+	if segments > 8 {
+		segments = 8
+	}
+	return strings.Repeat("#", segments) + strings.Repeat("-", 8-segments)
+}
+
+// TitleMenu offers a new game, and continuing the saved one if there is one.
+func (t *Text) TitleMenu(canContinue bool) {
+	areaText.StringToMap(image.Point{X: 17, Y: 24}, 10, 16, "M mute, -/+ volume")
+	areaText.StringToMap(image.Point{X: 12, Y: 23}, 7, 12, " New Game ")
+	if canContinue {
+		areaText.StringToMap(image.Point{X: 32, Y: 23}, 7, 12, " Continue ")
+		t.continueHitbox = Hitbox{
+			Rectangle: image.Rectangle{
+				Min: image.Point{X: 32 * 6, Y: 23 * 8},
+				Max: image.Point{X: (32 + 10) * 6, Y: 24 * 8},
+			},
 		}
 	}
 }
@@ -500,95 +871,176 @@ func (t *Text) SpeechBox(sx, sy, w, h int, col uint8) {
 	}
 }
 
-func (t *Text) updatePlotUI() {
-	text.PlayerSay("They're in plot...") //  " + strings.Join(verticalGridRef, "  ") + "\n  " + strings.Join(horizGridRef, "  "))
-
-	mousePos := marvlib.API.InputMousePos()
-	mousePos.X -= 6 * 11
-
-	for i, v := range verticalGridRef {
-		if i < lvlNum {
-			r := image.Rectangle{
-				Min: image.Point{X: 13 + (3+(i*3))*6, Y: 22 * 8},
-				Max: image.Point{X: 13 + ((3 + (i * 3)) * 6) + 6, Y: (22 * 8) + 8},
+// updatePlotKeys lets the plot be typed, e.g. C then 4, with Backspace to
+// correct and Enter to confirm.
+func (t *Text) updatePlotKeys() {
+	for _, c := range console.InputChars() {
+		switch c {
+		case '\b':
+			if t.selectedHoriz != "" {
+				t.selectedHoriz = ""
+			} else if t.typedVertical != "" {
+				t.typedVertical = t.typedVertical[:len(t.typedVertical)-1]
+				t.selectedVertical = graveyard.rowRef(t.typedVertical)
 			}
-			if mousePos.In(r) || t.selectedVertical == v {
-				areaText.StringToMap(image.Point{X: 13 + 3 + (i * 3), Y: 22}, 3, 14, v)
-				if marvlib.API.InputMousePressed() {
-					t.selectedVertical = v
+		case '\n', '\r':
+			if t.selectedHoriz != "" && t.selectedVertical != "" {
+				t.plotConfirmed = true
+			}
+		default:
+			key := strings.ToUpper(string(c))
+			if key >= "A" && key <= "Z" {
+				// rows can be more than one letter, so keep typing onto the
+				// row so far while it's still the start of a real one.
+				if !graveyard.hasRowPrefix(t.typedVertical + key) {
+					t.typedVertical = ""
 				}
-			} else {
-				areaText.StringToMap(image.Point{X: 13 + 3 + (i * 3), Y: 22}, 7, 10, v)
+				t.typedVertical += key
+				t.selectedVertical = graveyard.rowRef(t.typedVertical)
 			}
+			for _, h := range graveyard.colRefs() {
+				if h == key {
+					t.selectedHoriz = h
+				}
+			}
+		}
+	}
+}
+
+func (t *Text) updatePlotUI() {
+	t.updatePlotKeys()
+
+	text.PlayerSay("They're in plot... " + t.selectedVertical + t.selectedHoriz) //  " + strings.Join(verticalGridRef, "  ") + "\n  " + strings.Join(horizGridRef, "  "))
+
+	if v := t.plotRefsUI(t.rowRefsPage(graveyard.rowRefs()), 22, t.selectedVertical); v != "" {
+		t.selectedVertical = v
+		t.typedVertical = v
+		t.plotConfirmed = t.selectedHoriz != ""
+	}
+	if h := t.plotRefsUI(graveyard.colRefs(), 23, t.selectedHoriz); h != "" {
+		t.selectedHoriz = h
+		t.plotConfirmed = t.selectedVertical != ""
+	}
+}
+
+// plotRefsStartX and plotRefsEndX are the columns of the player's speech box
+// the grid refs go between.
+const plotRefsStartX, plotRefsEndX = 13 + 3, 13 + 40 - 1
+
+// refsWidth is the width of the longest ref.
+func refsWidth(refs []string) int {
+	width := 0
+	for _, ref := range refs {
+		if len(ref) > width {
+			width = len(ref)
 		}
 	}
-	for i, h := range horizGridRef {
+	return width
+}
+
+// rowRefsPage returns the rows that fit on the row picker's current page,
+// when there are too many to show at once, with arrows either side to turn
+// the page.
+func (t *Text) rowRefsPage(refs []string) []string {
+	spacing := refsWidth(refs) + 1
+	if len(refs)*spacing <= plotRefsEndX-plotRefsStartX {
+		return refs
+	}
+	// the arrows take a column each end.
+	perPage := (plotRefsEndX - 2 - plotRefsStartX) / spacing
+	pages := (len(refs) + perPage - 1) / perPage
+	if t.rowPage >= pages {
+		t.rowPage = pages - 1
+	}
+	if t.pageArrowUI(image.Point{X: plotRefsStartX - 2, Y: 22}, "<", t.rowPage > 0) {
+		t.rowPage--
+	}
+	if t.pageArrowUI(image.Point{X: plotRefsEndX - 1, Y: 22}, ">", t.rowPage < pages-1) {
+		t.rowPage++
+	}
+	start := t.rowPage * perPage
+	end := start + perPage
+	if end > len(refs) {
+		end = len(refs)
+	}
+	return refs[start:end]
+}
+
+// pageArrowUI draws a page turning arrow, if there's a page that way, and
+// returns whether it was clicked.
+func (t *Text) pageArrowUI(pos image.Point, arrow string, enabled bool) bool {
+	if !enabled {
+		return false
+	}
+	r := image.Rectangle{
+		Min: image.Point{X: pos.X * 6, Y: pos.Y * 8},
+		Max: image.Point{X: (pos.X + 1) * 6, Y: (pos.Y + 1) * 8},
+	}
+	if console.InputMousePos().In(r) {
+		areaText.StringToMap(pos, 3, 14, arrow)
+		return console.InputMousePressed()
+	}
+	areaText.StringToMap(pos, 7, 10, arrow)
+	return false
+}
+
+// plotRefsUI draws a line of grid refs in the player's speech box, closing
+// up the spacing if there are too many to fit, and returns the one clicked.
+func (t *Text) plotRefsUI(refs []string, y int, selected string) string {
+	width := refsWidth(refs)
+	spacing := width + 2
+	if len(refs)*spacing > plotRefsEndX-plotRefsStartX {
+		spacing = width + 1
+	}
+
+	mousePos := console.InputMousePos()
+	clicked := ""
+	for i, ref := range refs {
+		pos := image.Point{X: plotRefsStartX + (i * spacing), Y: y}
 		r := image.Rectangle{
-			Min: image.Point{X: 13 + (3+(i*3))*6, Y: 23 * 8},
-			Max: image.Point{X: 13 + ((3 + (i * 3)) * 6) + 6, Y: (23 * 8) + 8},
+			Min: image.Point{X: pos.X * 6, Y: pos.Y * 8},
+			Max: image.Point{X: (pos.X + len(ref)) * 6, Y: (pos.Y + 1) * 8},
 		}
-		if mousePos.In(r) || t.selectedHoriz == h {
-			areaText.StringToMap(image.Point{X: 13 + 3 + (i * 3), Y: 23}, 3, 14, h)
-			if marvlib.API.InputMousePressed() {
-				t.selectedHoriz = h
+		hover := mousePos.In(r)
+		if hover || selected == ref {
+			areaText.StringToMap(pos, 3, 14, ref)
+			if hover && console.InputMousePressed() {
+				clicked = ref
 			}
 		} else {
-			areaText.StringToMap(image.Point{X: 13 + 3 + (i * 3), Y: 23}, 7, 10, h)
+			areaText.StringToMap(pos, 7, 10, ref)
 		}
 	}
+	return clicked
 }
 
 func (t *Text) Update() {
-	marvlib.API.SpritesGet(SpriteMousePointer).ChangePos(image.Rectangle{
-		Min: marvlib.API.InputMousePos(),
+	console.SpritesGet(SpriteMousePointer).ChangePos(image.Rectangle{
+		Min: console.InputMousePos(),
 		Max: image.Point{X: 32, Y: 32},
 	})
 
-	if t.plotInput {
+	toggle := console.InputMousePressed() && t.notebookButton().IsHitNoCameraOffset(console.InputMousePos())
+	if !t.plotInput || t.notebookOpen { // otherwise N is a row.
+		for _, c := range console.InputChars() {
+			if c == 'n' || c == 'N' {
+				toggle = true
+			}
+		}
+	}
+	if toggle {
+		t.ToggleNotebook()
+	} else if t.notebookOpen {
+		t.updateNotebook()
+	} else if t.plotInput {
 		t.updatePlotUI()
 	}
 }
 
-const (
-	LIKE_HEADSTONE_CATS    = "They left their money to cats."
-	LIKE_HEADSTONE_DOGS    = "They left their money to dogs."
-	LIKE_HEADSTONE_CROSSES = "They really liked big crosses."
-	LIKE_HEADSTONE_WOOD    = "They really liked wood."
-	LIKE_HEADSTONE_WORDS   = "They really liked words."
-
-	LIKE_BODY_TALL  = "They were tall."
-	LIKE_BODY_SHORT = "They were short."
-
-	LIKE_WORE_GLASSES = "They wore stylish glasses."
-	LIKE_WORE_HAT     = "They famously wore a hat."
-	LIKE_WORE_BEARD   = "They were bearded."
-
-	LIKE_MARKER_FLOWERS   = "They loved flowers."
-	LIKE_MARKER_NOFLOWERS = "They hated flowers."
-)
-
+// The clue and trait tables are loaded from resources/clues.json by loadClues.
 var (
-	Likes = [][]string{
-		{LIKE_HEADSTONE_CATS, LIKE_HEADSTONE_DOGS, LIKE_HEADSTONE_CROSSES, LIKE_HEADSTONE_WOOD, LIKE_HEADSTONE_WORDS},
-		{LIKE_BODY_TALL, LIKE_BODY_SHORT},
-		{LIKE_WORE_GLASSES, LIKE_WORE_HAT, LIKE_WORE_BEARD},
-		{LIKE_MARKER_NOFLOWERS, LIKE_MARKER_FLOWERS},
-	}
-	Relations = []string{
-		"ancient ancestor",
-		"great aunt",
-		"great uncle",
-		"great grandfather",
-		"second cousin",
-		"favourite barber",
-		"favourite celebrity",
-		"old teacher",
-		"old neighbour",
-		"evil twin",
-		"old accountant",
-		"old dentist",
-		"disgraced plastic surgeon",
-	}
+	Likes     [][]string
+	Relations []string
 )
 
 type Hitbox struct {
@@ -613,16 +1065,14 @@ type Grave struct {
 	Hitbox       Hitbox
 	clickcounter int
 	DigDepth     int
+	dirt         int
 
-	headStone int
-	body      int
-	wore      [2]int
-	marker    int
+	traits []image.Point // the tile drawn for each of TraitCategories
 }
 
 func NewGrave(x, y int, gridX, gridY string) *Grave {
 	g := &Grave{
-		DigDepth: 10,
+		DigDepth: difficulty.DigDepth,
 		MapX:     x,
 		MapY:     y,
 		GridX:    gridX,
@@ -639,50 +1089,28 @@ func NewGrave(x, y int, gridX, gridY string) *Grave {
 }
 
 var (
-	DiggingPhrases = [][]string{
-		{""},
-		{"<digs> Let's dig this!", "<digs> Open says me!", "<digs> I'm a tomb spader!", "<digs> I'm a whom raider!", "<digs> Plot twist!"},
-		{"<digs more>"},
-		{"<digs more>", "<digs more> Phew!"},
-		{"<digs yet more>"},
-		{"<digs more>", "<digs more> Hit a stone!"},
-		{"<digs even more>"},
-		{"<digs more> Oof!", "<digs more> Feels like clay!"},
-		{"<digs more> Almost there!", "<digs more> Regulation depth!"},
-		{"*thunk* *thunk*", "*thud* *thud*", "*tink* *tink*"},
-	}
-
-	MapLikeToHeadStone = map[string]int{
-		LIKE_HEADSTONE_CATS:    5 * 4,
-		LIKE_HEADSTONE_DOGS:    6 * 4,
-		LIKE_HEADSTONE_CROSSES: 3 * 4,
-		LIKE_HEADSTONE_WOOD:    3 * 4,
-		LIKE_HEADSTONE_WORDS:   7 * 4,
-	}
-
-	MapLikeToBody = map[string][]int{
-		LIKE_BODY_TALL:  {2 * 4, 4 * 4},
-		LIKE_BODY_SHORT: {3 * 4, 5 * 4},
-	}
-
-	MapLikeToWore = map[string][2]int{
-		LIKE_WORE_BEARD:   [2]int{6 * 4, 3 * 4}, // DIFF ROW!!!
-		LIKE_WORE_GLASSES: [2]int{6 * 4, 2 * 4},
-		LIKE_WORE_HAT:     [2]int{7 * 4, 2 * 4},
-	}
-
-	MapLikeToMarker = map[string][]int{
-		LIKE_MARKER_FLOWERS:   {6 * 4, 7 * 4},
-		LIKE_MARKER_NOFLOWERS: {33},
-	}
+	DiggingPhrases [][]string
 )
 
+// Foot is where the gravedigger stands (feet position in pixels) to dig.
+func (g *Grave) Foot() image.Point {
+	return image.Point{X: (g.MapX * 8) + 16, Y: ((g.MapY + 10) * 8) + 6}
+}
+
 func (g *Grave) Dig() {
 	g.clickcounter++
 	text.VisitorSay("", "")
 	if g.clickcounter < g.DigDepth {
-		text.PlayerSay(DiggingPhrases[g.clickcounter][rand.Intn(len(DiggingPhrases[g.clickcounter]))])
+		// run through the phrases (and strokes) however deep the grave is.
+		stroke := g.clickcounter * (len(DiggingPhrases) - 1) / (g.DigDepth - 1)
+		text.PlayerSay(DiggingPhrases[stroke][rnd.Intn(len(DiggingPhrases[stroke]))])
+		g.drawDigging()
 		camera.Shake()
+		if g.clickcounter == g.DigDepth-1 {
+			audio.Thunk()
+		} else {
+			audio.DigStroke(stroke)
+		}
 	} else if g.clickcounter == g.DigDepth {
 		g.drawOpenGrave()
 		text.PlayerSay("That's got you, " + g.GridY + g.GridX)
@@ -691,65 +1119,49 @@ func (g *Grave) Dig() {
 	}
 }
 
-func (g *Grave) getHeadstoneFromLikes() {
-	g.headStone = 4 * 4 // TODO: random if no like???
-	for _, like := range g.Likes {
-		if idx, found := MapLikeToHeadStone[like]; found {
-			g.headStone = idx
+// wanted is whether one of today's visitors is looking for the grave.
+func (g *Grave) wanted() bool {
+	for _, visitor := range visitors.Visitors {
+		if visitor.Grave == g {
+			return true
 		}
 	}
+	return false
 }
-func (g *Grave) getBodyFromLikes() {
-	randBody := MapLikeToBody[LIKE_BODY_TALL]   // default to tall
-	g.body = randBody[rand.Intn(len(randBody))] // default to random as it doesn't matter
 
-	for _, like := range g.Likes {
-		if options, found := MapLikeToBody[like]; found {
-			g.body = options[rand.Intn(len(options))]
-		}
-	}
-}
-func (g *Grave) getWoreFromLikes() {
-	g.wore = [2]int{32, 32} // TODO: random if no like???
-	for _, like := range g.Likes {
-		if coords, found := MapLikeToWore[like]; found {
-			g.wore = coords
-		}
-	}
-}
-func (g *Grave) getMarkerFromLikes() {
-	g.marker = 32 // TODO: random if no like???
-	for _, like := range g.Likes {
-		if options, found := MapLikeToMarker[like]; found {
-			g.marker = options[rand.Intn(len(options))]
-		}
-	}
+// Refill fills an opened grave back in, as if it had never been dug.
+func (g *Grave) Refill() {
+	g.clickcounter = 0
+	g.drawClosedGrave()
+	text.VisitorSay("", "")
+	text.PlayerSay("<fills it back in>\nRest easy, " + g.Plot() + ".")
+	camera.Shake()
+	audio.Thunk()
 }
 
 func (g *Grave) generate() {
 	g.generateRelation()
 	g.generateLikes()
-	g.getHeadstoneFromLikes()
-	g.getBodyFromLikes()
-	g.getWoreFromLikes()
-	g.getMarkerFromLikes()
+	g.generateTraits()
+	g.dirt = 8 + (rnd.Intn(4) * 4) // random closed grave
 }
 
 func (g *Grave) generateRelation() {
-	g.Relation = Relations[rand.Intn(len(Relations))]
+	g.Relation = Relations[rnd.Intn(len(Relations))]
 }
 
 func (g *Grave) generateLikes() {
-	// shuffle and reduce to 3 rows of exclusive options
-	l := Likes
-	rand.Shuffle(len(l), func(i, j int) {
+	// shuffle and reduce to difficulty.Clues rows of exclusive options
+	// (shuffle a copy, otherwise Likes itself drifts between levels and seeds stop replaying)
+	l := append([][]string{}, Likes...)
+	rnd.Shuffle(len(l), func(i, j int) {
 		l[i], l[j] = l[j], l[i]
 	})
-	l = l[:3]
+	l = l[:difficulty.Clues]
 	// pick one exclusive option from each row
 	g.Likes = []string{}
 	for _, options := range l {
-		g.Likes = append(g.Likes, options[rand.Intn(len(options))])
+		g.Likes = append(g.Likes, options[rnd.Intn(len(options))])
 	}
 }
 
@@ -773,43 +1185,93 @@ func (g *Grave) drawCommonOverlay(dstX int, dstY int, srcX int, srcY int, w int,
 	}
 }
 
-func (g *Grave) drawHeadstone() {
-	g.drawCommon(g.MapX, g.MapY, g.headStone, 0, 4, 4) // headstone
+func (g *Grave) drawClosedGrave() {
+	g.clearTraits(false)
+	g.drawCommon(g.MapX, g.MapY+4, g.dirt, 4, 4, 6) // grave
+	g.clearTiles(areaOverlay, image.Point{X: 4, Y: 6}, image.Point{X: 4, Y: 4})
+	g.drawTraits(false)
 }
 
-func (g *Grave) drawMarker() {
-	g.drawCommonOverlay(g.MapX, g.MapY+3, g.marker, 4, 4, 4)
+func (g *Grave) draw() {
+	if g.clickcounter >= g.DigDepth {
+		g.drawOpenGrave()
+	} else if g.clickcounter > 0 {
+		g.drawDigging()
+	} else {
+		g.drawClosedGrave()
+	}
 }
 
-func (g *Grave) drawWore() {
-	g.drawCommonOverlay(g.MapX, g.MapY+2, g.wore[0], g.wore[1], 4, 6)
+// the dig progress tiles in resources/1_8x8.png, one per stage from the
+// left: holes are 4x6 tiles from row 24, and spoil heaps 4x4 from row 16,
+// both in the first 16 columns.
+const (
+	digStages      = 4
+	digHoleTileY   = 24
+	spoilHeapTileY = 16
+)
+
+// digStage is which of the dig progress tiles shows how far down the grave is.
+func (g *Grave) digStage() int {
+	if g.DigDepth <= 1 {
+		return digStages - 1
+	}
+	stage := (g.clickcounter - 1) * digStages / (g.DigDepth - 1)
+	if stage >= digStages {
+		stage = digStages - 1
+	}
+	return stage
 }
 
-func (g *Grave) drawClosedGrave() {
-	g.drawHeadstone()
-	r := 8 + (rand.Intn(4) * 4)                // random closed grave
-	g.drawCommon(g.MapX, g.MapY+4, r, 4, 4, 6) // grave
-	g.drawMarker()
+func (g *Grave) drawSpoilHeap(stage int) {
+	g.drawCommonOverlay(g.MapX+4, g.MapY+6, stage*4, spoilHeapTileY, 4, 4) // beside the grave
+}
+
+func (g *Grave) drawDigging() {
+	stage := g.digStage()
+	g.clearTraits(false)
+	g.drawCommon(g.MapX, g.MapY+4, stage*4, digHoleTileY, 4, 6) // hole
+	g.drawSpoilHeap(stage)
+	g.drawTraits(false)
 }
 
 func (g *Grave) drawOpenGrave() {
-	g.drawHeadstone()
-	g.drawCommon(g.MapX, g.MapY+4, g.body, 10, 4, 6) // grave
-	g.drawWore()
+	g.clearTraits(true)
+	g.drawCommon(g.MapX, g.MapY+4, (digStages-1)*4, digHoleTileY, 4, 6) // hole, under whatever's found in it
+	g.drawSpoilHeap(digStages - 1)
+	g.drawTraits(true)
 }
 
 var (
 	NPCId = SpriteVisitor1
 )
 
+const (
+	visitorOutside = iota
+	visitorArriving
+	visitorWaiting
+	visitorHappy
+	visitorAngry
+	visitorGone
+)
+
 type Visitor struct {
 	Pos      image.Point
 	SpriteId int
 	Grave    *Grave
 	Hitbox   Hitbox
 
-	w int
-	h int
+	w    int
+	h    int
+	look int
+
+	state    int
+	speed    int
+	path     []image.Point // feet positions to walk through
+	idle     *Task
+	patience int // frames they'll wait before giving up
+	talkedTo bool
+	dialogue visitorDialogue // from the level file, if there is one
 
 	done bool
 }
@@ -820,24 +1282,122 @@ func NewVisitor(grave *Grave) *Visitor {
 		Grave:    grave,
 		w:        4 * 8,
 		h:        8 * 8,
+		speed:    1,
 	}
 	NPCId++
-	marvlib.API.SpritesGet(v.SpriteId).Show(GfxBankPeople, areaPeople)
-	marvlib.API.SpritesGet(v.SpriteId).ChangeViewport(image.Point{X: (3 + rand.Intn(4)) * 32, Y: 0})
+	v.look = 3 + rnd.Intn(4)
+	v.patience = visitorPatienceMin + rnd.Intn(visitorPatienceMax-visitorPatienceMin)
+	console.SpritesGet(v.SpriteId).Show(GfxBankPeople, areaPeople)
+	console.SpritesGet(v.SpriteId).ChangeViewport(image.Point{X: v.look * 32, Y: 0})
 
-	v.Pos.X = 128 + 32 + ((v.SpriteId - SpriteVisitor1) * (40))
-	v.Pos.Y = 54
+	// wait outside the gate, then come in one after the other.
+	v.setFeet(gateOutside())
+	v.comeIn()
 
-	marvlib.API.SpritesGet(v.SpriteId).ChangePos(image.Rectangle{
-		Min: v.Pos,
-		Max: image.Point{X: v.w, Y: v.h},
-	})
-	marvlib.API.SpritesGet(v.SpriteId).SetSortIdx(v.Pos.Y)
-	marvlib.API.SpritesSort()
-	v.updateHitbox()
+	v.Update()
 	return v
 }
 
+const (
+	visitorPatienceMin = dayLengthFrames / 2
+	visitorPatienceMax = dayLengthFrames * 9 / 10
+)
+
+// mood is how the visitor is feeling about the wait so far.
+func (v *Visitor) mood() string {
+	switch {
+	case v.patience > visitorPatienceMin*2/3:
+		return "calm"
+	case v.patience > visitorPatienceMin/3:
+		return "restless"
+	}
+	return "fuming"
+}
+
+// gateBlock is the block of the top wall left open as the gate.
+const gateBlock = 3
+
+func gateInside() image.Point {
+	return image.Point{X: (gateBlock * 4 * 8) + 16, Y: 40}
+}
+
+func gateOutside() image.Point {
+	return image.Point{X: (gateBlock * 4 * 8) + 16, Y: -8}
+}
+
+// home is where the visitor waits their turn, in a row near the gate.
+func (v *Visitor) home() image.Point {
+	return image.Point{X: 128 + 32 + ((v.SpriteId - SpriteVisitor1) * (40)) + (v.w / 2), Y: 54 + v.h - 8}
+}
+
+func (v *Visitor) feet() image.Point {
+	return v.Pos.Add(image.Point{X: v.w / 2, Y: v.h - 8})
+}
+
+func (v *Visitor) setFeet(feet image.Point) {
+	v.Pos = feet.Sub(image.Point{X: v.w / 2, Y: v.h - 8})
+}
+
+// walkTo follows the paths to the target (a feet position), coming in
+// through the gate first if they're still outside.
+func (v *Visitor) walkTo(target image.Point) {
+	from := v.feet()
+	v.path = nil
+	if from.Y < gateInside().Y {
+		v.path = append(v.path, gateInside())
+		from = gateInside()
+	}
+	v.path = append(v.path, graveyard.walkGrid.FindPath(from, target)...)
+}
+
+func (v *Visitor) comeIn() {
+	v.idle = scheduler.After((v.SpriteId-SpriteVisitor1)*45, func() {
+		v.state = visitorArriving
+		v.walkTo(v.home())
+	})
+}
+
+// resume picks up whatever the visitor was doing, e.g. after a restore.
+func (v *Visitor) resume() {
+	switch v.state {
+	case visitorOutside:
+		v.comeIn()
+	case visitorArriving:
+		v.walkTo(v.home())
+	case visitorWaiting:
+		v.walkTo(v.home())
+		v.millAbout()
+	case visitorHappy:
+		v.walkTo(v.graveside())
+	case visitorAngry:
+		v.stormOut()
+	}
+}
+
+// graveside is where a happy visitor stands, just left of their grave.
+func (v *Visitor) graveside() image.Point {
+	return image.Point{X: (v.Grave.MapX - 2) * 8, Y: (v.Grave.MapY + 8) * 8}
+}
+
+func (v *Visitor) stormOut() {
+	v.state = visitorAngry
+	v.speed = 2
+	v.walkTo(gateInside())
+	v.path = append(v.path, gateOutside())
+}
+
+// millAbout wanders somewhere near home every so often while waiting.
+func (v *Visitor) millAbout() {
+	v.idle = scheduler.After(60+rnd.Intn(180), func() {
+		if v.state != visitorWaiting {
+			return
+		}
+		home := v.home()
+		v.walkTo(home.Add(image.Point{X: rnd.Intn(33) - 16, Y: rnd.Intn(17) - 8}))
+		v.millAbout()
+	})
+}
+
 func (v *Visitor) updateHitbox() {
 	v.Hitbox = Hitbox{
 		Rectangle: image.Rectangle{
@@ -847,46 +1407,169 @@ func (v *Visitor) updateHitbox() {
 	}
 }
 
+// ask is what the visitor says before their clues.
+func (v *Visitor) ask() string {
+	if v.dialogue.Ask != "" {
+		return v.dialogue.Ask
+	}
+	return "Where is my...\n ..." + v.Grave.Relation + "?"
+}
+
 func (v *Visitor) ChoosePlot(gridV, gridH string) {
 	selectedPlot := gridV + gridH
 	actualPlot := v.Grave.GridY + v.Grave.GridX
 
 	// fmt.Println(selectedPlot, actualPlot)
 
+	if v.idle.Active() {
+		v.idle.Cancel()
+	}
+
 	if selectedPlot == actualPlot {
+		thanks := v.dialogue.Thanks
+		if thanks == "" {
+			thanks = "It's so nice to see them\nagain! Although with perhaps\na touch more clarity than\nexpected...\n\nThank you!"
+		}
 		text.VisitorSay(
-			"It's so nice to see them\nagain! Although with perhaps\na touch more clarity than\nexpected...\n\nThank you!",
+			thanks,
 			fmt.Sprintf("Happy Visitor %d", (v.SpriteId-SpriteVisitor1)+1),
 		)
-		camera.TargetX = float64(player.X)
-		camera.TargetY = float64(player.Y)
+		camera.LookAt(player.X, player.Y)
 
-		v.Pos.X = (v.Grave.MapX + 3) * 8
-		v.Pos.Y = (v.Grave.MapY + 2) * 8
-		v.Hitbox = Hitbox{} // make untouchable
+		score += 100 * lvlNum
+		changeReputation(reputationHappy)
+		audio.Happy()
 
+		v.state = visitorHappy
+		v.walkTo(v.graveside())
 	} else {
+		wrong := v.dialogue.Wrong
+		if wrong == "" {
+			wrong = "You couldn't be more wrong!\nI'm off in a huff!\nTwo, if I can manage it!"
+		}
 		text.VisitorSay(
-			"You couldn't be more wrong!\nI'm off in a huff!\nTwo, if I can manage it!",
+			wrong,
 			fmt.Sprintf("Angry Visitor %d", (v.SpriteId-SpriteVisitor1)+1),
 		)
-		camera.TargetX = float64(player.X)
-		camera.TargetY = float64(player.Y)
-		v.Pos.Y = -128
-		v.Hitbox = Hitbox{} // make untouchable
+		camera.LookAt(player.X, player.Y)
+		changeReputation(-difficulty.AngryCost)
+		audio.Angry()
+		v.stormOut()
 	}
+	v.Hitbox = Hitbox{} // make untouchable
 
-	marvlib.API.SpritesGet(v.SpriteId).SetSortIdx(v.Pos.Y)
-	marvlib.API.SpritesSort()
+	v.done = true
+}
+
+// giveUp is ChoosePlot for a visitor who's run out of patience.
+func (v *Visitor) giveUp() {
+	if v.idle.Active() {
+		v.idle.Cancel()
+	}
+	text.VisitorSay(
+		"I've waited long enough!\nI'll find them myself.\nAnd I'll tell everyone\nwhat a shambles this place is!",
+		fmt.Sprintf("Angry Visitor %d", (v.SpriteId-SpriteVisitor1)+1),
+	)
+	if visitors.currentVisitor == v && text.plotInput {
+		text.PlayerSay("") // nobody left to tell.
+	}
+	changeReputation(-difficulty.AngryCost)
+	audio.Angry()
+	v.stormOut()
+	v.Hitbox = Hitbox{} // make untouchable
 
 	v.done = true
 }
 
+// closeUp sends home everyone still waiting (or yet to arrive) at the end of
+// the day. They're cross, but it's one mark against the place rather than
+// one each.
+func (v *Visitors) closeUp() {
+	waiting := 0
+	for _, visitor := range v.Visitors {
+		switch visitor.state {
+		case visitorOutside:
+			visitor.state = visitorGone
+		case visitorArriving, visitorWaiting:
+			visitor.stormOut()
+		default:
+			continue
+		}
+		if visitor.idle.Active() {
+			visitor.idle.Cancel()
+		}
+		if v.currentVisitor == visitor && text.plotInput {
+			text.PlayerSay("") // nobody left to tell.
+		}
+		visitor.Hitbox = Hitbox{} // make untouchable
+		visitor.done = true
+		waiting++
+	}
+	if waiting == 0 {
+		return
+	}
+	text.VisitorSay(
+		"We're closing for the night.\nThose still waiting grumble\nthat they'll be back\nwith a lantern.",
+		"Closing Time",
+	)
+	changeReputation(-difficulty.ClosingCost)
+	audio.Angry()
+}
+
+func (v *Visitor) move() {
+	for i := 0; i < v.speed && len(v.path) > 0; i++ {
+		next := v.path[0]
+		feet := v.feet()
+		v.Pos = v.Pos.Add(image.Point{X: sign(next.X - feet.X), Y: sign(next.Y - feet.Y)})
+		if v.feet() == next {
+			v.path = v.path[1:]
+		}
+	}
+}
+
 func (v *Visitor) Update() {
-	marvlib.API.SpritesGet(v.SpriteId).ChangePos(image.Rectangle{
-		Min: v.Pos.Sub(camera.GetAsPoint()),
+	v.move()
+
+	if len(v.path) == 0 {
+		switch v.state {
+		case visitorArriving:
+			v.state = visitorWaiting
+			v.millAbout()
+		case visitorAngry:
+			v.state = visitorGone
+		}
+	}
+	if v.state == visitorWaiting {
+		v.patience--
+		if v.patience <= 0 {
+			v.giveUp()
+		}
+	}
+	if v.state == visitorArriving || v.state == visitorWaiting {
+		v.updateHitbox()
+	}
+
+	cp := camera.GetAsPoint()
+	console.SpritesGet(v.SpriteId).ChangePos(image.Rectangle{
+		Min: v.Pos.Sub(cp),
 		Max: image.Point{X: v.w, Y: v.h},
 	})
+
+	ySortPos := v.Pos.Y
+	if ySortPos < 1 {
+		ySortPos = 1
+	}
+	console.SpritesGet(v.SpriteId).SetSortIdx(ySortPos)
+	console.SpritesSort()
+}
+
+func sign(n int) int {
+	if n < 0 {
+		return -1
+	} else if n > 0 {
+		return 1
+	}
+	return 0
 }
 
 type Visitors struct {
@@ -902,13 +1585,23 @@ func NewVisitors(numVisitors int) *Visitors {
 	for i := 0; i < numVisitors; i++ {
 		v.AddVisitor()
 	}
+	v.ensureUniqueTargets()
+	hideSpareVisitors()
 
 	return v
 }
 
+// hideSpareVisitors hides the visitor sprites nobody's using today, as
+// yesterday may have had more visitors.
+func hideSpareVisitors() {
+	for id := NPCId; id <= SpriteVisitor5; id++ {
+		console.SpritesGet(id).Hide()
+	}
+}
+
 func (v *Visitors) AddVisitor() {
 	// randomly pick a grave (that hasn't been chosen yet??? or could be same person so no matter!!!)
-	randomGrave := graveyard.graves[rand.Intn(len(graveyard.graves))]
+	randomGrave := graveyard.graves[rnd.Intn(len(graveyard.graves))]
 	newVisitor := NewVisitor(randomGrave)
 	v.Visitors = append(v.Visitors, newVisitor)
 }
@@ -923,6 +1616,15 @@ func (v *Visitors) GetClickedVisitor(screenpoint image.Point) *Visitor {
 }
 
 func (v *Visitors) Update() {
+	if reputation <= 0 {
+		mode = MODE_GAME_OVER_SETUP
+		return
+	}
+
+	if clock.Over() {
+		v.closeUp()
+	}
+
 	doneCount := 0
 	for _, visitor := range v.Visitors {
 		visitor.Update()
@@ -939,44 +1641,97 @@ func nextLevel() {
 	for _, visitor := range visitors.Visitors {
 		visitor.done = false // prevent success looping!
 	}
-	time.AfterFunc(6*time.Second, func() {
+	scheduler.After(6*framesPerSecond, func() {
+		if editPath != "" {
+			mode = MODE_EDITOR_SETUP // the level's been tried out.
+			return
+		}
+		if packComplete() {
+			mode = MODE_GAME_OVER_SETUP
+			return
+		}
 		lvlNum++
-		graveyard = &Graveyard{}
-		graveyard.Setup(lvlNum)
-		visitors = NewVisitors(5)
+		seedLevel(lvlNum)
+		rows := graveyard.rows
+		setupLevel()
+		clock = NewDayClock()
+		player.stamina = difficulty.Stamina
+		text.HUD()
 		player.flagDoneSomeDigging = false
 		player.flagTalkedToVisitors = false
-		text.VisitorSay("<The next day...>", fmt.Sprintf("Level %d", lvlNum))
+		text.VisitorSay("<The next day...>", levelBanner())
 		text.PlayerSay("")
-		text.PlayerSay("Another new day dawns!\nHas the graveyard got bigger!?\nMust be my eyes...")
+		if graveyard.rows > rows {
+			text.PlayerSay("Another new day dawns!\nHas the graveyard got bigger!?\nMust be my eyes...")
+		} else {
+			text.PlayerSay("Another new day dawns!\nSame old graveyard.\nSame old mystery.")
+		}
+		autosave()
 	})
 }
 
 var (
-	camera    *Camera
-	player    *Player
-	graveyard *Graveyard
-	text      *Text
-	visitors  *Visitors
-	lvlNum    = 1
-
-	areaUnderlay     marvtypes.MapBankArea
-	area             marvtypes.MapBankArea
-	areaOverlay      marvtypes.MapBankArea
-	areaMousePointer marvtypes.MapBankArea
-	areaPeople       marvtypes.MapBankArea
-	areaTitles       marvtypes.MapBankArea
-	areaText         marvtypes.MapBankArea
+	camera     *Camera
+	player     *Player
+	graveyard  *Graveyard
+	text       *Text
+	visitors   *Visitors
+	clock      *DayClock
+	scheduler  = &Scheduler{}
+	lvlNum     = 1
+	score      int
+	reputation int
+	seed       int64
+	rnd        = rand.New(rand.NewSource(0))
+
+	areaUnderlay     *TileMap
+	area             *TileMap
+	areaOverlay      *TileMap
+	windowUnderlay   MapBankArea
+	window           MapBankArea
+	windowOverlay    MapBankArea
+	areaMousePointer MapBankArea
+	areaPeople       MapBankArea
+	areaTitles       MapBankArea
+	areaText         MapBankArea
+)
+
+const (
+	reputationMax   = 100
+	reputationStart = 60
+	reputationHappy = 10
+	reputationAngry = -25 // on Normal, see Difficulty
+	// reputationClosing is lost once if anyone's still waiting at dusk (on
+	// Normal, see Difficulty).
+	reputationClosing = -10
 )
 
+func changeReputation(delta int) {
+	reputation += delta
+	if reputation > reputationMax {
+		reputation = reputationMax
+	}
+	if reputation < 0 {
+		reputation = 0
+	}
+	text.HUD()
+}
+
+// newRun resets everything that carries over between levels.
+func newRun() {
+	lvlNum = 1
+	score = 0
+	reputation = reputationStart
+}
+
 func setupAreas() {
-	areaPeople = marvlib.API.MapBanksGet(MapBankPeople).AllocArea(image.Point{X: 32, Y: 32})
-	areaTitles = marvlib.API.MapBanksGet(MapBankGraveyard).AllocArea(image.Point{X: 32, Y: 32})
-	areaOverlay = marvlib.API.MapBanksGet(MapBankGraveyard).AllocArea(image.Point{X: 64, Y: 249})
-	areaUnderlay = marvlib.API.MapBanksGet(MapBankGraveyard).AllocArea(image.Point{X: 64, Y: 249})
-	area = marvlib.API.MapBanksGet(MapBankGraveyard).AllocArea(image.Point{X: 64, Y: 249})
-	areaMousePointer = marvlib.API.MapBanksGet(MapBankGraveyard).AllocArea(image.Point{X: 4, Y: 4})
-	areaText = marvlib.API.MapBanksGet(MapBankText).AllocArea(image.Point{X: 54, Y: 25}) // full screen in default 6x8 font
+	areaPeople = console.MapBanksGet(MapBankPeople).AllocArea(image.Point{X: 32, Y: 32})
+	areaTitles = console.MapBanksGet(MapBankGraveyard).AllocArea(image.Point{X: 32, Y: 32})
+	windowOverlay = console.MapBanksGet(MapBankGraveyard).AllocArea(windowSize)
+	windowUnderlay = console.MapBanksGet(MapBankGraveyard).AllocArea(windowSize)
+	window = console.MapBanksGet(MapBankGraveyard).AllocArea(windowSize)
+	areaMousePointer = console.MapBanksGet(MapBankGraveyard).AllocArea(image.Point{X: 4, Y: 4})
+	areaText = console.MapBanksGet(MapBankText).AllocArea(image.Point{X: 54, Y: 25}) // full screen in default 6x8 font
 
 	// people
 	pos := image.Point{}
@@ -988,28 +1743,64 @@ func setupAreas() {
 	}
 }
 
+// SetSeed sets the game seed. Every level's random source is derived from it,
+// so the same seed always produces the same run of graveyards.
+func SetSeed(s int64) {
+	seed = s
+}
+
+// seedLevel resets rnd to the random source for the given level.
+func seedLevel(lvl int) {
+	rnd = rand.New(rand.NewSource(seed*1000003 + int64(lvl)))
+}
+
+func levelBanner() string {
+	if l := levelFor(lvlNum); l != nil && l.Name != "" {
+		return fmt.Sprintf("Level %d: %s", lvlNum, l.Name)
+	}
+	return fmt.Sprintf("Level %d Seed %d", lvlNum, seed)
+}
+
 func setupGame() {
-	camera = &Camera{X: 0, Y: 0}
+	seedLevel(lvlNum)
+	scheduler.Clear()
+	camera = NewCamera()
 	player = NewPlayer()
-	graveyard = &Graveyard{} // we need global refs to this before it sets up, hence the two set
-	graveyard.Setup(lvlNum)
+	setupLevel()
 	text = NewText()
-	visitors = NewVisitors(5)
+	clock = NewDayClock()
+	text.HUD()
+	audio.PlayMusic(musicGame)
 
+	text.VisitorSay("<A new day...>", levelBanner())
 	text.PlayerSay("I must talk to the visitors.\nAfter all, I'm here to help\nfind who they're looking for.")
 
 	mode = MODE_GAME
 }
 
 func updateGame() {
+	scheduler.Update()
+	audio.Update()
+	clock.Update()
 	camera.Update()
 	graveyard.Update()
 	player.Update()
 	text.Update()
+	text.updateTooltip()
 	visitors.Update()
 }
 
 func setupTitles() {
+	for id := SpriteGraveyardUnderlay; id <= SpriteMousePointer; id++ {
+		console.SpritesGet(id).Hide()
+		console.SpritesGet(id).ChangePalette(paletteDay)
+	}
+
+	text = NewText()
+	text.TitleMenu(hasSave())
+	text.DifficultyMenu()
+	audio.PlayMusic(musicTitle)
+
 	pos := image.Point{}
 	for pos.Y = 0; pos.Y < 32; pos.Y++ {
 		for pos.X = 0; pos.X < 32; pos.X++ {
@@ -1017,24 +1808,85 @@ func setupTitles() {
 		}
 	}
 
-	marvlib.API.SpritesGet(SpriteGraveyard).ChangePos(titleScreenRect)
-	marvlib.API.SpritesGet(SpriteGraveyard).Show(GfxBankTitles, areaTitles)
+	console.SpritesGet(SpriteGraveyard).ChangePos(titleScreenRect)
+	console.SpritesGet(SpriteGraveyard).Show(GfxBankTitles, areaTitles)
 
 	mode = MODE_TITLE_SCREEN
 }
 
 func updateTitles() {
-	if marvlib.API.InputMousePressed() {
+	text.Update()
+	audio.Update()
+	if console.InputMousePressed() {
+		if updateDifficultyMenu(console.InputMousePos()) {
+			return
+		}
+		if text.continueHitbox.IsHitNoCameraOffset(console.InputMousePos()) {
+			if save, err := loadGame(); err != nil {
+				log.Println("continue:", err)
+			} else {
+				continueGame(save)
+				return
+			}
+		}
+		newRun()
 		mode = MODE_GAME_SETUP
 	}
 }
 
+func setupGameOver() {
+	deleteSave() // the run is over, there's nothing to continue.
+	audio.StopMusic()
+	text.PlayerSay("")
+	text.SpeechBox(7, 9, 40, 8, 12)
+	if reputation > 0 && packComplete() {
+		areaText.StringToMap(image.Point{X: 8, Y: 10}, 10, 7, fmt.Sprintf(
+			"Every day of the level pack is done,\nand you kept your job!\n\nScore %d, reputation %d.\n\nClick to return to the title.",
+			score, reputation,
+		))
+		areaText.StringToMap(image.Point{X: 30, Y: 9}, 7, 12, " Pack Complete ")
+	} else {
+		areaText.StringToMap(image.Point{X: 8, Y: 10}, 10, 7, fmt.Sprintf(
+			"Nobody trusts you with a spade any\nmore. You're fired!\n\nScore %d, on day %d.\n\nClick to return to the title.",
+			score, lvlNum,
+		))
+		areaText.StringToMap(image.Point{X: 34, Y: 9}, 7, 12, " Game Over ")
+	}
+
+	mode = MODE_GAME_OVER
+}
+
+func updateGameOver() {
+	text.Update()
+	if console.InputMousePressed() {
+		mode = MODE_TITLE_SCREEN_SETUP
+		if editPath != "" {
+			mode = MODE_EDITOR_SETUP // back to the level being tried out.
+		}
+	}
+}
+
 func Start() {
+	if err := loadClues(); err != nil {
+		log.Fatalln(err)
+	}
+	if err := loadLevels(); err != nil {
+		log.Fatalln(err)
+	}
+	mode = MODE_TITLE_SCREEN_SETUP
+	if editPath != "" {
+		editor = nil
+		mode = MODE_EDITOR_SETUP
+	}
+	newRun()
 	setupAreas()
-	// marv.ModBanks[0].Play()
+	audio.loadSettings()
 }
 
 func Update() {
+	if in, ok := console.(frameInput); ok {
+		in.nextFrame()
+	}
 	switch mode {
 	case MODE_TITLE_SCREEN_SETUP:
 		setupTitles()
@@ -1047,5 +1899,13 @@ func Update() {
 		setupGame()
 	case MODE_GAME:
 		updateGame()
+	case MODE_GAME_OVER_SETUP:
+		setupGameOver()
+	case MODE_GAME_OVER:
+		updateGameOver()
+	case MODE_EDITOR_SETUP:
+		setupEditor()
+	case MODE_EDITOR:
+		updateEditor()
 	}
 }
@@ -0,0 +1,189 @@
+package cartridge
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// The notebook covers the whole text layer while it's open, with a page
+// for the visitors and a page for the graves dug so far.
+const (
+	notebookPageVisitors = iota
+	notebookPageGraves
+)
+
+const (
+	notebookTop    = 2  // first line of notes
+	notebookBottom = 22 // last line of notes, the one below is for paging
+	notebookCol1   = 3
+	notebookCol2   = 28
+)
+
+var notebookTabs = []struct {
+	page  int
+	x     int
+	label string
+}{
+	{notebookPageVisitors, 19, " Visitors "},
+	{notebookPageGraves, 30, " Graves "},
+}
+
+// noteText is a bit of text on a line of the notebook.
+type noteText struct {
+	x   int
+	fg  uint8
+	bg  uint8
+	txt string
+}
+
+// shortLike is a like as a note, e.g. "They were tall." is "were tall".
+func shortLike(like string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(like, "They "), ".")
+}
+
+// ObservedTraits are the likes the grave visibly shows once it's open.
+func (g *Grave) ObservedTraits() []string {
+	traits := []string{}
+	for _, options := range Likes {
+		for _, like := range options {
+			if g.HasTrait(like) {
+				traits = append(traits, like)
+			}
+		}
+	}
+	return traits
+}
+
+// likeLines puts the likes two to a line under a heading.
+func likeLines(likes []string) [][]noteText {
+	lines := [][]noteText{}
+	for i, like := range likes {
+		note := noteText{x: notebookCol1, fg: 10, bg: 7, txt: shortLike(like)}
+		if i%2 == 1 {
+			note.x = notebookCol2
+			lines[len(lines)-1] = append(lines[len(lines)-1], note)
+		} else {
+			lines = append(lines, []noteText{note})
+		}
+	}
+	return lines
+}
+
+func (v *Visitor) notebookStatus() string {
+	switch {
+	case v.state == visitorHappy:
+		return "found them"
+	case v.state == visitorAngry || v.state == visitorGone:
+		return "stormed off"
+	case v.talkedTo:
+		return v.mood()
+	}
+	return "not met yet"
+}
+
+func visitorNotes() [][]noteText {
+	lines := [][]noteText{}
+	for _, v := range visitors.Visitors {
+		heading := fmt.Sprintf("Visitor %d", (v.SpriteId-SpriteVisitor1)+1)
+		if v.talkedTo {
+			heading += ": " + v.Grave.Relation
+		}
+		status := v.notebookStatus()
+		lines = append(lines, []noteText{
+			{x: 2, fg: 7, bg: 12, txt: heading},
+			{x: 52 - len(status), fg: 10, bg: 7, txt: status},
+		})
+		if v.talkedTo {
+			lines = append(lines, likeLines(v.Grave.Likes)...)
+		}
+		lines = append(lines, nil)
+	}
+	return lines
+}
+
+func graveNotes() [][]noteText {
+	lines := [][]noteText{}
+	for _, g := range graveyard.graves {
+		if g.clickcounter < g.DigDepth {
+			continue
+		}
+		lines = append(lines, []noteText{{x: 2, fg: 7, bg: 12, txt: "Plot " + g.Plot()}})
+		lines = append(lines, likeLines(g.ObservedTraits())...)
+		lines = append(lines, nil)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, []noteText{{x: 2, fg: 10, bg: 7, txt: "Nothing dug up yet."}})
+	}
+	return lines
+}
+
+// ToggleNotebook opens or closes the notebook, putting back whatever was
+// being said when it closes.
+func (t *Text) ToggleNotebook() {
+	t.notebookOpen = !t.notebookOpen
+	t.Clear(0, 0, 54, 25)
+	if t.notebookOpen {
+		t.notebookScroll = 0
+		t.drawNotebook()
+		return
+	}
+	t.redraw()
+}
+
+func (t *Text) drawNotebook() {
+	t.SpeechBox(0, 0, 54, 25, 12)
+	areaText.StringToMap(image.Point{X: 2, Y: 0}, 7, 12, " Case Notebook ")
+	for _, tab := range notebookTabs {
+		if tab.page == t.notebookPage {
+			areaText.StringToMap(image.Point{X: tab.x, Y: 0}, 3, 14, tab.label)
+		} else {
+			areaText.StringToMap(image.Point{X: tab.x, Y: 0}, 7, 12, tab.label)
+		}
+	}
+	areaText.StringToMap(image.Point{X: 41, Y: 0}, 7, 12, " Close [N] ")
+
+	lines := visitorNotes()
+	if t.notebookPage == notebookPageGraves {
+		lines = graveNotes()
+	}
+	perPage := notebookBottom - notebookTop + 1
+	if t.notebookScroll >= len(lines) {
+		t.notebookScroll = 0
+	}
+	for i, line := range lines[t.notebookScroll:] {
+		if i >= perPage {
+			break
+		}
+		for _, note := range line {
+			areaText.StringToMap(image.Point{X: note.x, Y: notebookTop + i}, note.fg, note.bg, note.txt)
+		}
+	}
+	if len(lines) > perPage {
+		page := fmt.Sprintf(" Page %d of %d, click the tab for more ", 1+t.notebookScroll/perPage, (len(lines)+perPage-1)/perPage)
+		areaText.StringToMap(image.Point{X: 52 - len(page), Y: 24}, 7, 12, page)
+	}
+}
+
+// updateNotebook handles the tabs, clicking the open tab again turns the
+// page.
+func (t *Text) updateNotebook() {
+	if console.InputMousePressed() {
+		mousePos := console.InputMousePos()
+		for _, tab := range notebookTabs {
+			r := image.Rectangle{
+				Min: image.Point{X: tab.x * 6, Y: 0},
+				Max: image.Point{X: (tab.x + len(tab.label)) * 6, Y: 8},
+			}
+			if mousePos.In(r) {
+				if tab.page == t.notebookPage {
+					t.notebookScroll += notebookBottom - notebookTop + 1
+				} else {
+					t.notebookPage = tab.page
+					t.notebookScroll = 0
+				}
+			}
+		}
+	}
+	t.drawNotebook()
+}
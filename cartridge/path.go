@@ -0,0 +1,161 @@
+package cartridge
+
+import (
+	"container/heap"
+	"image"
+	"math"
+)
+
+const walkTileSize = 8
+
+// WalkGrid marks which tiles of the graveyard the gravedigger can stand on.
+// It's read from the graveyard's main layer, where anything other than the
+// cleared tile is a wall, headstone or grave.
+type WalkGrid struct {
+	size    image.Point
+	blocked []bool
+}
+
+func NewWalkGrid(a *TileMap) *WalkGrid {
+	wg := &WalkGrid{
+		size:    a.size,
+		blocked: make([]bool, a.size.X*a.size.Y),
+	}
+	pos := image.Point{}
+	for pos.Y = 0; pos.Y < wg.size.Y; pos.Y++ {
+		for pos.X = 0; pos.X < wg.size.X; pos.X++ {
+			x, y, _, _ := a.Get(pos)
+			wg.blocked[wg.idx(pos)] = x != 31 || y != 0
+		}
+	}
+	return wg
+}
+
+func (wg *WalkGrid) idx(cell image.Point) int {
+	return cell.Y*wg.size.X + cell.X
+}
+
+func (wg *WalkGrid) Walkable(cell image.Point) bool {
+	return cell.In(image.Rectangle{Max: wg.size}) && !wg.blocked[wg.idx(cell)]
+}
+
+// nearestWalkable finds the closest walkable tile to cell, searching outwards.
+func (wg *WalkGrid) nearestWalkable(cell image.Point) (image.Point, bool) {
+	if wg.Walkable(cell) {
+		return cell, true
+	}
+	seen := map[image.Point]bool{cell: true}
+	queue := []image.Point{cell}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, d := range walkDirs {
+			n := c.Add(d)
+			if seen[n] || !n.In(image.Rectangle{Max: wg.size}) {
+				continue
+			}
+			if wg.Walkable(n) {
+				return n, true
+			}
+			seen[n] = true
+			queue = append(queue, n)
+		}
+	}
+	return image.Point{}, false
+}
+
+var walkDirs = []image.Point{
+	{X: 1}, {X: -1}, {Y: 1}, {Y: -1},
+	{X: 1, Y: 1}, {X: 1, Y: -1}, {X: -1, Y: 1}, {X: -1, Y: -1},
+}
+
+// FindPath returns the pixel waypoints from one pixel position to another,
+// ending as close to the target as can be reached. The start doesn't have to
+// be walkable, so someone stood in a bad spot can still walk out of it.
+func (wg *WalkGrid) FindPath(from, to image.Point) []image.Point {
+	start := from.Div(walkTileSize)
+	goal, found := wg.nearestWalkable(to.Div(walkTileSize))
+	if !found {
+		return nil
+	}
+	if goal != to.Div(walkTileSize) {
+		to = goal.Mul(walkTileSize).Add(image.Point{X: walkTileSize / 2, Y: walkTileSize / 2})
+	}
+
+	cameFrom := map[image.Point]image.Point{}
+	cost := map[image.Point]float64{start: 0}
+	open := &pathQueue{}
+	heap.Push(open, pathNode{cell: start, priority: octile(start, goal)})
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(pathNode).cell
+		if current == goal {
+			return wg.waypoints(cameFrom, start, goal, to)
+		}
+		for _, d := range walkDirs {
+			next := current.Add(d)
+			if !wg.Walkable(next) {
+				continue
+			}
+			if d.X != 0 && d.Y != 0 {
+				// no cutting corners round headstones.
+				if !wg.Walkable(current.Add(image.Point{X: d.X})) || !wg.Walkable(current.Add(image.Point{Y: d.Y})) {
+					continue
+				}
+			}
+			step := 1.0
+			if d.X != 0 && d.Y != 0 {
+				step = math.Sqrt2
+			}
+			newCost := cost[current] + step
+			if old, seen := cost[next]; !seen || newCost < old {
+				cost[next] = newCost
+				cameFrom[next] = current
+				heap.Push(open, pathNode{cell: next, priority: newCost + octile(next, goal)})
+			}
+		}
+	}
+	return nil
+}
+
+// waypoints turns the cells A* found into pixel positions at each change of
+// direction, finishing exactly on the target.
+func (wg *WalkGrid) waypoints(cameFrom map[image.Point]image.Point, start, goal, to image.Point) []image.Point {
+	cells := []image.Point{goal}
+	for c := goal; c != start; {
+		c = cameFrom[c]
+		cells = append([]image.Point{c}, cells...)
+	}
+
+	points := []image.Point{}
+	for i := 1; i < len(cells)-1; i++ {
+		if cells[i].Sub(cells[i-1]) != cells[i+1].Sub(cells[i]) {
+			points = append(points, cells[i].Mul(walkTileSize).Add(image.Point{X: walkTileSize / 2, Y: walkTileSize / 2}))
+		}
+	}
+	return append(points, to)
+}
+
+func octile(a, b image.Point) float64 {
+	dx := math.Abs(float64(a.X - b.X))
+	dy := math.Abs(float64(a.Y - b.Y))
+	return math.Max(dx, dy) + (math.Sqrt2-1)*math.Min(dx, dy)
+}
+
+type pathNode struct {
+	cell     image.Point
+	priority float64
+}
+
+type pathQueue []pathNode
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathNode)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
@@ -0,0 +1,101 @@
+package cartridge
+
+import (
+	"image"
+	"testing"
+)
+
+// walkGrid makes a grid from rows of text, where # is blocked.
+func walkGrid(rows ...string) *WalkGrid {
+	m := NewTileMap(image.Point{X: len(rows[0]), Y: len(rows)}, nowhere{})
+	m.Clear(31, 0)
+	for y, row := range rows {
+		for x, c := range row {
+			if c == '#' {
+				m.Set(image.Point{X: x, Y: y}, 0, 0, 0, 0)
+			}
+		}
+	}
+	return NewWalkGrid(m)
+}
+
+// cellCentre is the pixel in the middle of a cell.
+func cellCentre(x, y int) image.Point {
+	return image.Point{X: x*walkTileSize + walkTileSize/2, Y: y*walkTileSize + walkTileSize/2}
+}
+
+func TestFindPath(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		grid     []string
+		from, to image.Point
+		want     []image.Point
+	}{
+		{
+			"straight",
+			[]string{
+				".....",
+			},
+			cellCentre(0, 0), cellCentre(4, 0),
+			[]image.Point{cellCentre(4, 0)},
+		},
+		{
+			"around a grave",
+			[]string{
+				".....",
+				"..#..",
+				"..#..",
+				".....",
+			},
+			cellCentre(0, 1), cellCentre(4, 1),
+			[]image.Point{cellCentre(1, 0), cellCentre(3, 0), cellCentre(4, 1)},
+		},
+		{
+			"no cutting corners",
+			[]string{
+				"..",
+				"#.",
+			},
+			cellCentre(0, 0), cellCentre(1, 1),
+			[]image.Point{cellCentre(1, 0), cellCentre(1, 1)},
+		},
+		{
+			"to a grave stops beside it",
+			[]string{
+				"...",
+				"..#",
+			},
+			cellCentre(0, 1), cellCentre(2, 1),
+			[]image.Point{cellCentre(1, 1)},
+		},
+		{
+			"walled off",
+			[]string{
+				"..#..",
+				"..#..",
+			},
+			cellCentre(0, 0), cellCentre(4, 0),
+			nil,
+		},
+		{
+			"nowhere to stand",
+			[]string{
+				"###",
+			},
+			cellCentre(0, 0), cellCentre(2, 0),
+			nil,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := walkGrid(test.grid...).FindPath(test.from, test.to)
+			if len(got) != len(test.want) {
+				t.Fatalf("path %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("path %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
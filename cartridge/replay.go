@@ -0,0 +1,183 @@
+package cartridge
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+)
+
+// A replay file is JSON lines: a ReplayHeader, then a ReplayFrame for every
+// call to Update. Played back with the same seed and save the game makes the
+// same choices, so a session comes out the same frame for frame.
+
+// ReplayHeader is everything the run depended on before the first frame.
+type ReplayHeader struct {
+	Seed int64 `json:"seed"`
+	// Difficulty is what New Game would have started with.
+	Difficulty string `json:"difficulty,omitempty"`
+	// Save is the save Continue would have loaded when recording started.
+	Save json.RawMessage `json:"save,omitempty"`
+	// Levels are the hand made level files played, from PlayLevel or
+	// PlayLevelPack, as they were when recording started.
+	Levels []json.RawMessage `json:"levels,omitempty"`
+	// LevelPack is whether the run ends after the last of the Levels.
+	LevelPack bool `json:"levelPack,omitempty"`
+}
+
+// ReplayFrame is the mouse and keyboard for a single frame.
+type ReplayFrame struct {
+	Pos     image.Point `json:"pos"`
+	Pressed bool        `json:"pressed,omitempty"`
+	Chars   string      `json:"chars,omitempty"`
+}
+
+type Replay struct {
+	ReplayHeader
+	Frames []ReplayFrame
+}
+
+// frameInput is a console that holds its input still for each frame,
+// Update moves it on.
+type frameInput interface {
+	nextFrame()
+}
+
+// inputConsole answers the input calls from the frame it's holding.
+type inputConsole struct {
+	Console
+	frame ReplayFrame
+}
+
+func (c *inputConsole) InputMousePressed() bool {
+	return c.frame.Pressed
+}
+
+func (c *inputConsole) InputMousePos() image.Point {
+	return c.frame.Pos
+}
+
+func (c *inputConsole) InputChars() []rune {
+	return []rune(c.frame.Chars)
+}
+
+// recordConsole writes each frame's input out as it's read.
+type recordConsole struct {
+	inputConsole
+	enc *json.Encoder
+	err error
+}
+
+func (c *recordConsole) nextFrame() {
+	if in, ok := c.Console.(frameInput); ok {
+		in.nextFrame() // recording a replay.
+	}
+	c.frame = ReplayFrame{
+		Pos:     c.Console.InputMousePos(),
+		Pressed: c.Console.InputMousePressed(),
+		Chars:   string(c.Console.InputChars()),
+	}
+	if c.err != nil {
+		return
+	}
+	if c.err = c.enc.Encode(c.frame); c.err != nil {
+		log.Println("record:", c.err)
+	}
+}
+
+// replayConsole feeds the cartridge recorded frames. Once they run out the
+// mouse stays where it was, unpressed.
+type replayConsole struct {
+	inputConsole
+	frames []ReplayFrame
+}
+
+func (c *replayConsole) nextFrame() {
+	if len(c.frames) == 0 {
+		c.frame.Pressed = false
+		c.frame.Chars = ""
+		return
+	}
+	c.frame = c.frames[0]
+	c.frames = c.frames[1:]
+}
+
+// Record writes every frame of input from now on to w. Call it after
+// SetSeed, UseConsole and PlayLevel (or PlayLevelPack), and before Start.
+// The level editor can't be recorded, as its level file changes as it goes.
+func Record(w io.Writer) error {
+	if editPath != "" {
+		return errors.New("can't record the level editor")
+	}
+	header := ReplayHeader{Seed: seed, Difficulty: difficulty.Name, LevelPack: packOnly}
+	sources, err := levelSources()
+	if err != nil {
+		return err
+	}
+	header.Levels = sources
+	if s, err := loadGame(); err == nil {
+		b, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		header.Save = b
+	}
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	console = &recordConsole{inputConsole: inputConsole{Console: console}, enc: enc}
+	return nil
+}
+
+// LoadReplay reads a file written by Record.
+func LoadReplay(r io.Reader) (*Replay, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	rp := &Replay{}
+	if err := dec.Decode(&rp.ReplayHeader); err != nil {
+		return nil, fmt.Errorf("replay header: %w", err)
+	}
+	if difficultyNamed(rp.Difficulty) == nil {
+		return nil, fmt.Errorf("replay header: no %q difficulty", rp.Difficulty)
+	}
+	for {
+		var frame ReplayFrame
+		err := dec.Decode(&frame)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// a recording cut short by a crash still replays up to there.
+			log.Printf("replay frame %d: %v", len(rp.Frames), err)
+			break
+		}
+		rp.Frames = append(rp.Frames, frame)
+	}
+	return rp, nil
+}
+
+// PlayReplay sets the seed and levels and feeds the cartridge the recorded
+// input in place of the console's own. Saves are kept in memory so the
+// replay neither reads nor overwrites the real one. Call it after UseConsole,
+// and before Start.
+func PlayReplay(rp *Replay) {
+	seed = rp.Seed
+	levelPaths = nil
+	levelJSON = rp.Levels
+	packOnly = rp.LevelPack
+	difficulty = difficultyNamed(rp.Difficulty)
+	savesInMemory = true
+	memorySave = rp.Save
+	console = &replayConsole{inputConsole: inputConsole{Console: console}, frames: rp.Frames}
+}
+
+// ReplayFinished reports whether a replay has used up all its frames.
+func ReplayFinished() bool {
+	if c, ok := console.(*replayConsole); ok {
+		return len(c.frames) == 0
+	}
+	return false
+}
@@ -0,0 +1,338 @@
+package cartridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+var configDir string
+
+// replays and the bot keep their saves here rather than on disk, so they
+// neither read nor overwrite the player's own.
+var (
+	savesInMemory bool
+	memorySave    []byte
+)
+
+// SetConfigDir overrides where saves and settings are kept, which is
+// otherwise losttheplot in the user's config directory.
+func SetConfigDir(dir string) {
+	configDir = dir
+}
+
+func configPath(name string) (string, error) {
+	if configDir != "" {
+		return filepath.Join(configDir, name), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "losttheplot", name), nil
+}
+
+func savePath() (string, error) {
+	return configPath("save.json")
+}
+
+// saveGame is everything needed to put a run back exactly as it was.
+type saveGame struct {
+	Seed       int64
+	Level      int
+	Rows       int // of graves
+	Cols       int // plots across
+	Difficulty string
+	Score      int
+	Reputation int
+	Clock      int // frames into the day
+	Player     savedPlayer
+	Graves     []savedGrave
+	Visitors   []savedVisitor
+	Levels     []*levelFile // the hand made ones being played, if any
+	LevelPack  bool         // the run ends after the last of the Levels
+}
+
+type savedPlayer struct {
+	X, Y                 float64
+	Stamina              int
+	FlagTalkedToVisitors bool
+	FlagDoneSomeDigging  bool
+}
+
+type savedGrave struct {
+	MapX, MapY   int
+	GridX, GridY string
+	Relation     string
+	Likes        []string
+	DigDepth     int
+	ClickCounter int
+	Dirt         int
+	Traits       []image.Point // tiles, in TraitCategories order
+}
+
+type savedVisitor struct {
+	Grave     int // index into Graves
+	Look      int
+	Pos       image.Point
+	State     int
+	Patience  int
+	TalkedTo  bool
+	Done      bool
+	Touchable bool
+	Dialogue  visitorDialogue
+}
+
+func newSaveGame() *saveGame {
+	s := &saveGame{
+		Seed:       seed,
+		Level:      lvlNum,
+		Rows:       graveyard.rows,
+		Cols:       graveyard.cols,
+		Difficulty: difficulty.Name,
+		Score:      score,
+		Reputation: reputation,
+		Clock:      clock.Frame,
+		Levels:     levels,
+		LevelPack:  packOnly,
+		Player: savedPlayer{
+			X:                    player.X,
+			Y:                    player.Y,
+			Stamina:              player.stamina,
+			FlagTalkedToVisitors: player.flagTalkedToVisitors,
+			FlagDoneSomeDigging:  player.flagDoneSomeDigging,
+		},
+	}
+	graveIdx := map[*Grave]int{}
+	for i, g := range graveyard.graves {
+		graveIdx[g] = i
+		s.Graves = append(s.Graves, savedGrave{
+			MapX:         g.MapX,
+			MapY:         g.MapY,
+			GridX:        g.GridX,
+			GridY:        g.GridY,
+			Relation:     g.Relation,
+			Likes:        g.Likes,
+			DigDepth:     g.DigDepth,
+			ClickCounter: g.clickcounter,
+			Dirt:         g.dirt,
+			Traits:       g.traits,
+		})
+	}
+	for _, v := range visitors.Visitors {
+		s.Visitors = append(s.Visitors, savedVisitor{
+			Grave:     graveIdx[v.Grave],
+			Look:      v.look,
+			Pos:       v.Pos,
+			State:     v.state,
+			Patience:  v.patience,
+			TalkedTo:  v.talkedTo,
+			Done:      v.done,
+			Touchable: v.Hitbox != Hitbox{},
+			Dialogue:  v.dialogue,
+		})
+	}
+	return s
+}
+
+func (s *saveGame) graves() ([]*Grave, error) {
+	graves := []*Grave{}
+	for i, sg := range s.Graves {
+		g := NewGrave(sg.MapX, sg.MapY, sg.GridX, sg.GridY)
+		g.Relation = sg.Relation
+		g.Likes = sg.Likes
+		g.DigDepth = sg.DigDepth
+		g.clickcounter = sg.ClickCounter
+		g.dirt = sg.Dirt
+		g.traits = sg.Traits
+		if len(g.traits) != len(TraitCategories) {
+			return nil, fmt.Errorf("grave %d has %d traits, want %d", i, len(g.traits), len(TraitCategories))
+		}
+		graves = append(graves, g)
+	}
+	return graves, nil
+}
+
+func (s *saveGame) visitors(graves []*Grave) (*Visitors, error) {
+	NPCId = SpriteVisitor1
+	vs := &Visitors{}
+	for _, sv := range s.Visitors {
+		if sv.Grave < 0 || sv.Grave >= len(graves) {
+			return nil, fmt.Errorf("visitor grave %d out of range", sv.Grave)
+		}
+		v := NewVisitor(graves[sv.Grave])
+		v.look = sv.Look
+		v.Pos = sv.Pos
+		v.state = sv.State
+		v.patience = sv.Patience
+		v.talkedTo = sv.TalkedTo
+		v.done = sv.Done
+		v.dialogue = sv.Dialogue
+		v.updateHitbox()
+		if !sv.Touchable {
+			v.Hitbox = Hitbox{}
+		}
+		if v.idle.Active() {
+			v.idle.Cancel()
+		}
+		v.resume()
+		console.SpritesGet(v.SpriteId).ChangeViewport(image.Point{X: v.look * 32, Y: 0})
+		vs.Visitors = append(vs.Visitors, v)
+	}
+	return vs, nil
+}
+
+// writeConfig saves v as JSON to the named file in the config directory.
+func writeConfig(name string, v interface{}) error {
+	path, err := configPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// readConfig loads the named JSON file in the config directory into v.
+func readConfig(name string, v interface{}) error {
+	path, err := configPath(name)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func saveGameToFile(s *saveGame) error {
+	if savesInMemory {
+		b, err := json.Marshal(s)
+		memorySave = b
+		return err
+	}
+	return writeConfig("save.json", s)
+}
+
+func loadGame() (*saveGame, error) {
+	s := &saveGame{}
+	if savesInMemory {
+		if memorySave == nil {
+			return nil, os.ErrNotExist
+		}
+		if err := json.Unmarshal(memorySave, s); err != nil {
+			return nil, fmt.Errorf("save: %w", err)
+		}
+		return s, nil
+	}
+	if err := readConfig("save.json", s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func hasConfig(name string) bool {
+	path, err := configPath(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+func hasSave() bool {
+	if savesInMemory {
+		return memorySave != nil
+	}
+	return hasConfig("save.json")
+}
+
+func deleteSave() {
+	if savesInMemory {
+		memorySave = nil
+		return
+	}
+	if path, err := savePath(); err == nil {
+		os.Remove(path)
+	}
+}
+
+func autosave() {
+	if err := saveGameToFile(newSaveGame()); err != nil {
+		log.Println("autosave:", err)
+	}
+}
+
+// continueGame is setupGame for a saved run.
+func continueGame(s *saveGame) {
+	d := difficultyNamed(s.Difficulty)
+	if d == nil {
+		log.Printf("continue: no %q difficulty", s.Difficulty)
+		newRun()
+		mode = MODE_GAME_SETUP
+		return
+	}
+	for i, l := range s.Levels {
+		if err := l.validate(); err != nil {
+			log.Printf("continue: level %d: %v", i+1, err)
+			newRun()
+			mode = MODE_GAME_SETUP
+			return
+		}
+	}
+	levels, packOnly = s.Levels, s.LevelPack
+	seed = s.Seed
+	lvlNum = s.Level
+	score = s.Score
+	reputation = s.Reputation
+	difficulty = d
+	seedLevel(lvlNum)
+	scheduler.Clear()
+
+	camera = NewCamera()
+	player = NewPlayer()
+	player.X, player.Y = s.Player.X, s.Player.Y
+	player.stamina = s.Player.Stamina
+	player.flagTalkedToVisitors = s.Player.FlagTalkedToVisitors
+	player.flagDoneSomeDigging = s.Player.FlagDoneSomeDigging
+
+	graves, err := s.graves()
+	if err != nil {
+		log.Println("continue:", err)
+		newRun()
+		mode = MODE_GAME_SETUP
+		return
+	}
+	graveyard = &Graveyard{}
+	graveyard.Restore(s.Rows, s.Cols, graves)
+	text = NewText()
+	clock = NewDayClock()
+	clock.Frame = s.Clock
+	text.HUD()
+	vs, err := s.visitors(graves)
+	if err != nil {
+		log.Println("continue:", err)
+		newRun()
+		mode = MODE_GAME_SETUP
+		return
+	}
+	visitors = vs
+	audio.PlayMusic(musicGame)
+
+	text.VisitorSay("<Back to work...>", levelBanner())
+	text.PlayerSay("Right, where was I?")
+
+	mode = MODE_GAME
+}
@@ -0,0 +1,109 @@
+package cartridge
+
+const framesPerSecond = 60
+
+// Scheduler runs delayed callbacks and timed effects from the game loop, so
+// everything they touch is changed on the Update goroutine. Time is counted
+// in frames, which keeps it deterministic.
+type Scheduler struct {
+	frame   int
+	tasks   []*Task
+	cleared int // times Clear's been called, so Update can tell a callback did
+}
+
+type Task struct {
+	start     int
+	frames    int
+	tick      func(progress float64)
+	done      func()
+	cancelled bool
+}
+
+// After calls fn once the given number of frames have passed.
+func (s *Scheduler) After(frames int, fn func()) *Task {
+	return s.add(&Task{frames: frames, done: fn})
+}
+
+// Tween calls tick every frame for the given number of frames, with
+// progress going from just above 0 up to 1 on the last one.
+func (s *Scheduler) Tween(frames int, tick func(progress float64)) *Task {
+	return s.add(&Task{frames: frames, tick: tick})
+}
+
+func (s *Scheduler) add(t *Task) *Task {
+	t.start = s.frame
+	s.tasks = append(s.tasks, t)
+	return t
+}
+
+// Then calls fn when the task finishes (but not if it's cancelled).
+func (t *Task) Then(fn func()) *Task {
+	t.done = fn
+	return t
+}
+
+func (t *Task) Cancel() {
+	t.cancelled = true
+}
+
+// Active reports whether the task has yet to finish or be cancelled.
+func (t *Task) Active() bool {
+	return t != nil && !t.cancelled && t.frames >= 0
+}
+
+// Clear cancels everything that's pending, including the rest of this
+// frame's tasks if a callback calls it. Anything scheduled after it still runs.
+func (s *Scheduler) Clear() {
+	cancelAll(s.tasks)
+	s.tasks = nil
+	s.cleared++
+}
+
+func cancelAll(tasks []*Task) {
+	for _, t := range tasks {
+		t.Cancel()
+	}
+}
+
+// Update moves time on a frame and runs whatever is due, in the order it
+// was scheduled. Tasks scheduled by those callbacks start from this frame.
+func (s *Scheduler) Update() {
+	s.frame++
+	due := s.tasks
+	s.tasks = nil
+	pending := []*Task{}
+	cleared := s.cleared
+	for i, t := range due {
+		if s.cleared != cleared {
+			cancelAll(due[i:])
+			break
+		}
+		if t.cancelled {
+			continue
+		}
+		elapsed := s.frame - t.start
+		if t.tick != nil && t.frames > 0 {
+			progress := float64(elapsed) / float64(t.frames)
+			if progress > 1 {
+				progress = 1
+			}
+			t.tick(progress)
+		}
+		if t.cancelled {
+			continue
+		}
+		if elapsed >= t.frames {
+			t.frames = -1 // finished
+			if t.done != nil {
+				t.done()
+			}
+			continue
+		}
+		pending = append(pending, t)
+	}
+	if s.cleared != cleared {
+		cancelAll(pending)
+		return
+	}
+	s.tasks = append(pending, s.tasks...)
+}
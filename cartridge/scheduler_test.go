@@ -0,0 +1,30 @@
+package cartridge_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/TheMightyGit/losttheplot/cartridge"
+)
+
+func TestClearFromCallback(t *testing.T) {
+	s := &cartridge.Scheduler{}
+	ran := []string{}
+	earlier := s.Tween(10, func(float64) { ran = append(ran, "earlier") })
+	s.After(1, func() {
+		ran = append(ran, "clear")
+		s.Clear()
+		s.After(1, func() { ran = append(ran, "after clear") })
+	})
+	later := s.After(1, func() { ran = append(ran, "later") })
+
+	for i := 0; i < 3; i++ {
+		s.Update()
+	}
+	if want := "[earlier clear after clear]"; fmt.Sprint(ran) != want {
+		t.Fatalf("ran %v, want %s", ran, want)
+	}
+	if earlier.Active() || later.Active() {
+		t.Fatal("tasks from before the clear still active")
+	}
+}
@@ -0,0 +1,34 @@
+package cartridge
+
+import (
+	"fmt"
+	"testing"
+)
+
+// generated is a level's worth of graves made up from the seed.
+func generated(s int64, lvl int) string {
+	SetSeed(s)
+	seedLevel(lvl)
+	txt := ""
+	for i := 0; i < 10; i++ {
+		g := &Grave{}
+		g.generate()
+		txt += fmt.Sprintln(g.Relation, g.Likes)
+	}
+	return txt
+}
+
+func TestSeedReplays(t *testing.T) {
+	if err := loadClues(); err != nil {
+		t.Fatal(err)
+	}
+	if generated(42, 3) != generated(42, 3) {
+		t.Fatal("the same seed and level made different graves")
+	}
+	if generated(42, 3) == generated(43, 3) {
+		t.Fatal("different seeds made the same graves")
+	}
+	if generated(42, 3) == generated(42, 4) {
+		t.Fatal("different levels made the same graves")
+	}
+}
@@ -0,0 +1,197 @@
+package cartridge
+
+// Plot is the grid reference players use for a grave, e.g. "C4".
+func (g *Grave) Plot() string {
+	return g.GridY + g.GridX
+}
+
+// HasTrait reports whether the grave visibly shows what the like describes,
+// going by the tiles drawn rather than the grave's own Likes (some likes share
+// a tile, e.g. crosses and wood).
+func (g *Grave) HasTrait(like string) bool {
+	cat := likeCategory(like)
+	if cat < 0 {
+		return false
+	}
+	tiles, _ := TraitCategories[cat].tiles(like)
+	return containsPoint(tiles, g.traits[cat])
+}
+
+// Matches reports whether the grave fits every one of the clues.
+func (g *Grave) Matches(likes []string) bool {
+	for _, like := range likes {
+		if !g.HasTrait(like) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchingGraves returns every grave in the graveyard that fits the clues.
+func (g *Graveyard) MatchingGraves(likes []string) []*Grave {
+	matches := []*Grave{}
+	for _, grave := range g.graves {
+		if grave.Matches(likes) {
+			matches = append(matches, grave)
+		}
+	}
+	return matches
+}
+
+// MatchingPlots is MatchingGraves as plot references.
+func (g *Graveyard) MatchingPlots(likes []string) []string {
+	plots := []string{}
+	for _, grave := range g.MatchingGraves(likes) {
+		plots = append(plots, grave.Plot())
+	}
+	return plots
+}
+
+// likeCategory returns which row of Likes (and so which of TraitCategories)
+// the like comes from, or -1.
+func likeCategory(like string) int {
+	for cat, options := range Likes {
+		for _, option := range options {
+			if option == like {
+				return cat
+			}
+		}
+	}
+	return -1
+}
+
+// maxClues is as many clues as a visitor gives, any more and they'd run off
+// the bottom of the speech box (see speechMaxLines).
+const maxClues = 4
+
+// addClue gives the grave a like from a category it doesn't have a clue for
+// yet, returning false if it already has one from every category (or as many
+// as the visitor can say).
+func (g *Grave) addClue() bool {
+	if len(g.Likes) >= maxClues {
+		return false
+	}
+	used := map[int]bool{}
+	for _, like := range g.Likes {
+		used[likeCategory(like)] = true
+	}
+	unused := []int{}
+	for cat := range Likes {
+		if !used[cat] {
+			unused = append(unused, cat)
+		}
+	}
+	if len(unused) == 0 {
+		return false
+	}
+	options := Likes[unused[rnd.Intn(len(unused))]]
+	g.Likes = append(g.Likes, options[rnd.Intn(len(options))])
+	g.generateTraits()
+	g.drawClosedGrave()
+	return true
+}
+
+// ensureUniqueTargets adds clues to, or regenerates, graves until each
+// visitor's grave is the only one in the graveyard matching its clues. The
+// last difficulty.Ambiguous visitors are given a lookalike instead, and have
+// to be guessed.
+func (v *Visitors) ensureUniqueTargets() {
+	unique := len(v.Visitors) - difficulty.Ambiguous
+	for ambiguous := true; ambiguous; {
+		ambiguous = false
+		for i, visitor := range v.Visitors {
+			if i >= unique {
+				break
+			}
+			matches := graveyard.MatchingGraves(visitor.Grave.Likes)
+			if len(matches) == 1 {
+				continue
+			}
+			ambiguous = true
+			if !visitor.Grave.addClue() {
+				// already all the clues we can give, so the others are
+				// lookalikes and need a new identity.
+				for _, grave := range matches {
+					if grave != visitor.Grave {
+						grave.generate()
+						grave.drawClosedGrave()
+					}
+				}
+			}
+		}
+	}
+	v.addLookalikes(unique)
+}
+
+// addLookalikes makes another grave fit the clues of each visitor after the
+// first unique, as long as it doesn't also fit those of any of the first
+// unique, who must still have only the one.
+func (v *Visitors) addLookalikes(unique int) {
+	if unique < 0 {
+		unique = 0
+	}
+	taken := map[*Grave]bool{}
+	for _, visitor := range v.Visitors {
+		taken[visitor.Grave] = true
+	}
+	for _, visitor := range v.Visitors[unique:] {
+		if len(graveyard.MatchingGraves(visitor.Grave.Likes)) > 1 {
+			continue // one by chance already.
+		}
+		// try every grave nobody's looking for, there may be none that'll
+		// do, e.g. if someone else is after the same grave.
+		for _, i := range rnd.Perm(len(graveyard.graves)) {
+			grave := graveyard.graves[i]
+			if taken[grave] {
+				continue
+			}
+			likes, traits := grave.Likes, grave.traits
+			grave.Likes = append([]string{}, visitor.Grave.Likes...)
+			grave.generateTraits()
+			if v.unique(unique) {
+				grave.drawClosedGrave()
+				taken[grave] = true
+				break
+			}
+			grave.Likes, grave.traits = likes, traits
+		}
+	}
+}
+
+// unique reports whether each of the first n visitors' graves is the only
+// one matching their clues.
+func (v *Visitors) unique(n int) bool {
+	for _, visitor := range v.Visitors[:n] {
+		if len(graveyard.MatchingGraves(visitor.Grave.Likes)) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// regenerateLookalikes gives a new identity to any made up grave that fits a
+// visitor's clues, so in a hand made level they only have the graves it was
+// designed with to choose between. The graves in keep (the designed ones,
+// even if they're lookalikes on purpose) are left alone, as are the graves
+// being looked for.
+func (v *Visitors) regenerateLookalikes(keep map[*Grave]bool) {
+	targets := map[*Grave]bool{}
+	for _, visitor := range v.Visitors {
+		targets[visitor.Grave] = true
+	}
+	for lookalikes := true; lookalikes; {
+		lookalikes = false
+		for _, visitor := range v.Visitors {
+			if len(visitor.Grave.Likes) == 0 {
+				continue // everything fits, however it's made up.
+			}
+			for _, grave := range graveyard.MatchingGraves(visitor.Grave.Likes) {
+				if !keep[grave] && !targets[grave] {
+					grave.generate()
+					grave.drawClosedGrave()
+					lookalikes = true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package cartridge
+
+import (
+	"image"
+	"testing"
+)
+
+// nowhere is a map area that draws nothing, for tests that don't look.
+type nowhere struct{}
+
+func (nowhere) Set(image.Point, uint8, uint8, uint8, uint8)   {}
+func (nowhere) Clear(uint8, uint8)                            {}
+func (nowhere) StringToMap(image.Point, uint8, uint8, string) {}
+
+func TestUniqueTargets(t *testing.T) {
+	if err := loadClues(); err != nil {
+		t.Fatal(err)
+	}
+	size := image.Point{X: graveyardMapWidth, Y: 64}
+	area, areaOverlay = NewTileMap(size, nowhere{}), NewTileMap(size, nowhere{})
+	const (
+		wood    = "They really liked wood."
+		crosses = "They really liked big crosses."
+		tall    = "They were tall."
+		short   = "They were short."
+		hat     = "They famously wore a hat."
+		beard   = "They were bearded."
+		flowers = "They loved flowers."
+	)
+	for _, test := range []struct {
+		name    string
+		graves  [][]string // each grave's likes
+		targets []int      // the graves visitors are looking for
+	}{
+		{"twins", [][]string{{wood, tall, hat}, {wood, tall, hat}}, []int{0}},
+		{"both twins visited", [][]string{{wood, tall, hat}, {wood, tall, hat}}, []int{0, 1}},
+		{"same tile for two clues", [][]string{{crosses, short, beard}, {wood, short, beard}}, []int{1}},
+		{"a clue from every row", [][]string{{wood, tall, hat, flowers}, {wood, tall, hat, flowers}}, []int{0}},
+		{"a crowd", [][]string{
+			{wood, tall, hat}, {wood, tall, hat}, {wood, tall, hat}, {wood, tall, hat},
+			{wood, tall, hat, flowers}, {wood, tall, hat, flowers},
+		}, []int{0, 3, 4}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			SetSeed(1)
+			seedLevel(1)
+			graveyard = &Graveyard{}
+			for i, likes := range test.graves {
+				g := NewGrave(i*4, 0, "1", string(rune('A'+i)))
+				g.Likes = append([]string{}, likes...)
+				g.generateTraits()
+				graveyard.graves = append(graveyard.graves, g)
+			}
+			vs := &Visitors{}
+			for _, i := range test.targets {
+				vs.Visitors = append(vs.Visitors, &Visitor{Grave: graveyard.graves[i]})
+			}
+
+			vs.ensureUniqueTargets()
+			for i, v := range vs.Visitors {
+				matches := graveyard.MatchingGraves(v.Grave.Likes)
+				if len(matches) != 1 || matches[0] != v.Grave {
+					t.Errorf("visitor %d's clues %q fit %v", i+1, v.Grave.Likes, graveyard.MatchingPlots(v.Grave.Likes))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package cartridge
+
+import "image"
+
+// TileMap is one layer of the graveyard (underlay, main or overlay), kept in
+// memory and sized to the level, as it can grow taller than a map bank. The
+// console only ever sees a screen sized window onto it, refilled by show as
+// the camera moves.
+type TileMap struct {
+	size   image.Point
+	tiles  []tile
+	blank  tile
+	window MapBankArea
+	origin image.Point // top left tile of the window last filled
+	dirty  bool
+}
+
+type tile struct {
+	x, y, fg, bg uint8
+}
+
+// windowSize is a screen of tiles, plus one each way for the camera being
+// part way across a tile.
+var windowSize = fullScreenRect.Max.Div(8).Add(image.Point{X: 1, Y: 1})
+
+func NewTileMap(size image.Point, window MapBankArea) *TileMap {
+	return &TileMap{
+		size:   size,
+		tiles:  make([]tile, size.X*size.Y),
+		window: window,
+		dirty:  true,
+	}
+}
+
+func (m *TileMap) in(pos image.Point) bool {
+	return pos.In(image.Rectangle{Max: m.size})
+}
+
+func (m *TileMap) Get(pos image.Point) (x, y, fg, bg uint8) {
+	if !m.in(pos) {
+		return m.blank.x, m.blank.y, m.blank.fg, m.blank.bg
+	}
+	t := m.tiles[pos.Y*m.size.X+pos.X]
+	return t.x, t.y, t.fg, t.bg
+}
+
+func (m *TileMap) Set(pos image.Point, x, y, fg, bg uint8) {
+	if m.in(pos) {
+		m.tiles[pos.Y*m.size.X+pos.X] = tile{x, y, fg, bg}
+		m.dirty = true
+	}
+}
+
+// Clear fills the layer with the tile, which is also what the window shows
+// past its edges.
+func (m *TileMap) Clear(x, y uint8) {
+	m.blank = tile{x: x, y: y}
+	for i := range m.tiles {
+		m.tiles[i] = m.blank
+	}
+	m.dirty = true
+}
+
+// show fills the window from the tiles under the camera (in graveyard
+// pixels), if they've moved or changed since, and returns where in the
+// window the camera is.
+func (m *TileMap) show(camera image.Point) image.Point {
+	origin := image.Point{X: floorDiv(camera.X, 8), Y: floorDiv(camera.Y, 8)}
+	if m.dirty || origin != m.origin {
+		pos := image.Point{}
+		for pos.Y = 0; pos.Y < windowSize.Y; pos.Y++ {
+			for pos.X = 0; pos.X < windowSize.X; pos.X++ {
+				x, y, fg, bg := m.Get(origin.Add(pos))
+				m.window.Set(pos, x, y, fg, bg)
+			}
+		}
+		m.origin = origin
+		m.dirty = false
+	}
+	return camera.Sub(origin.Mul(8))
+}
+
+// floorDiv rounds towards minus infinity, as a shaking camera can go just
+// past the top left of the graveyard.
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((b - 1 - a) / b)
+	}
+	return a / b
+}
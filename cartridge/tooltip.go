@@ -0,0 +1,99 @@
+package cartridge
+
+import (
+	"image"
+	"strings"
+)
+
+// joinLikes lists likes as notes, dropping words the later ones share with
+// the first, e.g. "really liked big crosses or wood".
+func joinLikes(likes []string) string {
+	first := strings.Fields(shortLike(likes[0]))
+	notes := []string{strings.Join(first, " ")}
+	for _, like := range likes[1:] {
+		words := strings.Fields(shortLike(like))
+		for i := 0; i < len(first) && len(words) > 1 && words[0] == first[i]; i++ {
+			words = words[1:]
+		}
+		notes = append(notes, strings.Join(words, " "))
+	}
+	return strings.Join(notes, " or ")
+}
+
+// TraitLines spells out what each part of the grave shows.
+func (g *Grave) TraitLines() []string {
+	traits := g.ObservedTraits()
+	lines := []string{}
+	for cat, category := range TraitCategories {
+		shown := []string{}
+		for _, like := range traits {
+			if likeCategory(like) == cat {
+				shown = append(shown, like)
+			}
+		}
+		line := category.Name + ": "
+		if len(shown) > 0 {
+			line += joinLikes(shown)
+		} else {
+			line += "nothing of note"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// updateTooltip shows what an opened grave reveals while the mouse is
+// over it, putting back whatever it covered when it goes.
+func (t *Text) updateTooltip() {
+	mousePos := console.InputMousePos()
+	at := image.Point{X: mousePos.X / 6, Y: mousePos.Y / 8}
+
+	var grave *Grave
+	if !t.notebookOpen {
+		if g := graveyard.GetClickedGrave(mousePos); g != nil && g.clickcounter >= g.DigDepth {
+			grave = g
+		}
+	}
+	if t.tooltipGrave != nil && (grave != t.tooltipGrave || at != t.tooltipAt) && !t.notebookOpen {
+		r := t.tooltipRect
+		t.Clear(r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+		t.redraw()
+	}
+	t.tooltipGrave, t.tooltipAt = grave, at
+	if grave != nil {
+		// drawn every frame so nothing said since gets left on top.
+		t.drawTooltip(grave, at)
+	}
+}
+
+func (t *Text) drawTooltip(g *Grave, at image.Point) {
+	lines := append(g.TraitLines(), "(click to fill it back in)")
+	w := 0
+	for _, line := range lines {
+		if len(line) > w {
+			w = len(line)
+		}
+	}
+	w += 2
+	h := len(lines) + 2
+
+	// just below and right of the pointer, but kept on screen.
+	pos := at.Add(image.Point{X: 2, Y: 2})
+	if pos.X+w > 54 {
+		pos.X = 54 - w
+	}
+	if pos.Y+h > 25 {
+		pos.Y = at.Y - h - 1
+	}
+	if pos.X < 0 {
+		pos.X = 0
+	}
+	if pos.Y < 0 {
+		pos.Y = 0
+	}
+	t.tooltipRect = image.Rectangle{Min: pos, Max: pos.Add(image.Point{X: w, Y: h})}
+
+	t.SpeechBox(pos.X, pos.Y, w, h, 12)
+	areaText.StringToMap(pos.Add(image.Point{X: 1, Y: 1}), 10, 7, strings.Join(lines, "\n"))
+	areaText.StringToMap(pos.Add(image.Point{X: w - len(g.Plot()) - 4, Y: 0}), 7, 12, " "+g.Plot()+" ")
+}
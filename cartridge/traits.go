@@ -0,0 +1,139 @@
+package cartridge
+
+import "image"
+
+// the graveyard layers a trait can be drawn on.
+const (
+	traitLayerGraveyard = "graveyard"
+	traitLayerOverlay   = "overlay"
+)
+
+// TraitCategory is one kind of thing a grave shows about whoever is in it,
+// e.g. their headstone or what they wore. Each has its own row of clues, the
+// tiles that show them and where on the grave they go.
+//
+// Tiles are the top left of a Size block in the graveyard gfx bank, and
+// where there's a choice one is picked at random. Default is what a grave
+// gets when it has no clue for the category, and is allowed to sit off the
+// sheet so it draws nothing.
+type TraitCategory struct {
+	Name    string        `json:"name"`
+	Layer   string        `json:"layer"`
+	Offset  image.Point   `json:"offset"` // from the grave's MapX, MapY
+	Size    image.Point   `json:"size"`
+	Closed  bool          `json:"closed"` // shown before it's dug (and while digging)
+	Open    bool          `json:"open"`   // shown once it's dug up
+	Clues   []TraitClue   `json:"clues"`
+	Default []image.Point `json:"default"`
+}
+
+type TraitClue struct {
+	Text  string        `json:"text"`
+	Tiles []image.Point `json:"tiles"` // none means it looks like the default
+}
+
+// TraitCategories is the trait registry, loaded from resources/clues.json by
+// loadClues. Likes has a row per category, in the same order.
+var TraitCategories []*TraitCategory
+
+// tiles returns the tile options for the like, if it's one of this
+// category's clues.
+func (c *TraitCategory) tiles(like string) ([]image.Point, bool) {
+	for _, clue := range c.Clues {
+		if clue.Text == like {
+			if len(clue.Tiles) == 0 {
+				return c.Default, true
+			}
+			return clue.Tiles, true
+		}
+	}
+	return nil, false
+}
+
+func (c *TraitCategory) likes() []string {
+	likes := []string{}
+	for _, clue := range c.Clues {
+		likes = append(likes, clue.Text)
+	}
+	return likes
+}
+
+// shown reports whether the category is drawn on a grave that is (or isn't)
+// dug up.
+func (c *TraitCategory) shown(open bool) bool {
+	if open {
+		return c.Open
+	}
+	return c.Closed
+}
+
+func (c *TraitCategory) area() *TileMap {
+	if c.Layer == traitLayerOverlay {
+		return areaOverlay
+	}
+	return area
+}
+
+func containsPoint(options []image.Point, p image.Point) bool {
+	for _, option := range options {
+		if option == p {
+			return true
+		}
+	}
+	return false
+}
+
+// generateTraits picks what each category looks like on the grave, from its
+// clue if it has one or the category's default if not.
+func (g *Grave) generateTraits() {
+	g.traits = make([]image.Point, len(TraitCategories))
+	for i, category := range TraitCategories {
+		options := category.Default
+		for _, like := range g.Likes {
+			if tiles, found := category.tiles(like); found {
+				options = tiles
+			}
+		}
+		g.traits[i] = options[rnd.Intn(len(options))]
+	}
+}
+
+// clearTraits clears away the traits that aren't shown in this state of the
+// grave. It goes before drawing the dirt or hole, as some share its tiles.
+func (g *Grave) clearTraits(open bool) {
+	for _, category := range TraitCategories {
+		if !category.shown(open) {
+			g.clearTiles(category.area(), category.Offset, category.Size)
+		}
+	}
+}
+
+// drawTraits draws the traits shown in this state of the grave.
+func (g *Grave) drawTraits(open bool) {
+	for i, category := range TraitCategories {
+		if category.shown(open) {
+			tile := g.traits[i]
+			g.drawTiles(category.area(), category.Offset, tile, category.Size)
+		}
+	}
+}
+
+func (g *Grave) drawTiles(dst *TileMap, offset, src, size image.Point) {
+	offset = offset.Add(image.Point{X: g.MapX, Y: g.MapY})
+	pos := image.Point{}
+	for pos.Y = 0; pos.Y < size.Y; pos.Y++ {
+		for pos.X = 0; pos.X < size.X; pos.X++ {
+			dst.Set(pos.Add(offset), uint8(src.X+pos.X), uint8(src.Y+pos.Y), 0, 0)
+		}
+	}
+}
+
+func (g *Grave) clearTiles(dst *TileMap, offset, size image.Point) {
+	offset = offset.Add(image.Point{X: g.MapX, Y: g.MapY})
+	pos := image.Point{}
+	for pos.Y = 0; pos.Y < size.Y; pos.Y++ {
+		for pos.X = 0; pos.X < size.X; pos.X++ {
+			dst.Set(pos.Add(offset), 31, 0, 0, 0) // empty
+		}
+	}
+}
@@ -1,15 +1,98 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/TheMightyGit/losttheplot/cartridge"
+	"github.com/TheMightyGit/losttheplot/cartridge/headless"
 	"github.com/TheMightyGit/marv/marvlib"
 )
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
+	seed := flag.Int64("seed", 0, "game seed to replay a run of graveyards (picked at random if not given)")
+	record := flag.String("record", "", "record every frame of input, and any -level or -levels files, to this replay file (not with -edit)")
+	replay := flag.String("replay", "", "play back a replay file instead of reading the mouse and keyboard")
+	bot := flag.Bool("bot", false, "let the solver bot play")
+	days := flag.Int("days", 3, "with -bot, stop after this many days (0 plays on until game over)")
+	runs := flag.Int("runs", 1, "with -bot -headless, how many seeds to play, counting up from -seed")
+	edit := flag.String("edit", "", "open this level file (new or existing) in the level editor")
+	level := flag.String("level", "", "play this level file, made with -edit, as the first day")
+	levelPack := flag.String("levels", "", "play the level files in this directory in name order, one a day, instead of made up graveyards")
+	difficulty := flag.String("difficulty", "", "difficulty new games start on, Easy, Normal or Hard (it can still be changed at the titles)")
+	runHeadless := flag.Bool("headless", false, "with -replay or -bot, run without a window and print how it went")
+	flag.Parse()
+
+	if !flagGiven("seed") {
+		*seed = time.Now().UnixNano()
+	}
+
+	if *difficulty != "" {
+		if err := cartridge.SetDifficulty(*difficulty); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if *level != "" {
+		cartridge.PlayLevel(*level)
+	}
+	if *levelPack != "" {
+		if err := cartridge.PlayLevelPack(*levelPack); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if *edit != "" {
+		cartridge.EditLevel(*edit)
+	}
+
+	if *bot && *runHeadless {
+		if !botHeadless(*seed, *runs, *days) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *replay != "" {
+		f, err := os.Open(*replay)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		rp, err := cartridge.LoadReplay(f)
+		f.Close()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		log.Println("replaying", *replay, "seed", rp.Seed, "frames", len(rp.Frames))
+		if *runHeadless {
+			replayHeadless(rp)
+			return
+		}
+		cartridge.PlayReplay(rp)
+	} else {
+		log.Println("seed", *seed)
+		cartridge.SetSeed(*seed)
+	}
+	if *bot {
+		cartridge.PlayBot(*days)
+	}
+
+	if *record != "" {
+		f, err := os.Create(*record)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer f.Close()
+		if err := cartridge.Record(f); err != nil {
+			log.Fatalln(err)
+		}
+		log.Println("recording to", *record)
+	}
+
+	cartridge.UseConsole(marvConsole{})
 	marvlib.API.ConsoleBoot(
 		"losttheplot",
 		cartridge.Resources,
@@ -17,3 +100,65 @@ func main() {
 		cartridge.Update,
 	)
 }
+
+// flagGiven reports whether the named flag was on the command line, as any
+// value it takes could have been asked for.
+func flagGiven(name string) bool {
+	given := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			given = true
+		}
+	})
+	return given
+}
+
+// replayHeadless runs a replay to the end on the headless console.
+func replayHeadless(rp *cartridge.Replay) {
+	c := headless.New()
+	cartridge.UseConsole(c)
+	cartridge.PlayReplay(rp)
+	cartridge.Start()
+	for !cartridge.ReplayFinished() {
+		c.Step()
+		cartridge.Update()
+	}
+	if text := c.MapBanks[cartridge.MapBankText]; text != nil && len(text.Areas) > 0 {
+		fmt.Println(text.Areas[0].Text())
+	}
+}
+
+// botHeadless lets the bot play runs seeds without a window, reporting how
+// each went. It returns false if the bot ran into any problems.
+func botHeadless(seed int64, runs, days int) bool {
+	total := cartridge.BotReport{}
+	for i := 0; i < runs; i++ {
+		c := headless.New()
+		cartridge.UseConsole(c)
+		cartridge.SetSeed(seed + int64(i))
+		cartridge.PlayBot(days)
+		cartridge.Start()
+		for !cartridge.BotFinished() {
+			c.Step()
+			cartridge.Update()
+		}
+
+		r := cartridge.BotResult()
+		end := ""
+		if r.GameOver {
+			end = " game over"
+		}
+		fmt.Printf("seed %d: level %d score %d reputation %d found %d wrong %d gave up %d%s\n",
+			r.Seed, r.Level, r.Score, r.Reputation, r.Found, r.Wrong, r.GaveUp, end)
+		for _, problem := range r.Problems {
+			fmt.Println("  problem:", problem)
+		}
+		total.Found += r.Found
+		total.Wrong += r.Wrong
+		total.GaveUp += r.GaveUp
+		total.Problems = append(total.Problems, r.Problems...)
+	}
+	fmt.Printf("%d runs: found %d wrong %d gave up %d, %d problems\n",
+		runs, total.Found, total.Wrong, total.GaveUp, len(total.Problems))
+	return len(total.Problems) == 0
+}
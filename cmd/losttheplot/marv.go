@@ -0,0 +1,69 @@
+package main
+
+import (
+	"image"
+
+	"github.com/TheMightyGit/losttheplot/cartridge"
+	"github.com/TheMightyGit/marv/marvlib"
+	"github.com/TheMightyGit/marv/marvtypes"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// marvConsole is the cartridge's console on marv.
+type marvConsole struct{}
+
+func (marvConsole) SpritesGet(id int) cartridge.Sprite {
+	return marvSprite{marvlib.API.SpritesGet(id)}
+}
+
+func (marvConsole) SpritesSort() {
+	marvlib.API.SpritesSort()
+}
+
+func (marvConsole) MapBanksGet(id int) cartridge.MapBank {
+	return marvMapBank{marvlib.API.MapBanksGet(id)}
+}
+
+func (marvConsole) ModBanksGet(id int) cartridge.ModBank {
+	return marvlib.API.ModBanksGet(id)
+}
+
+func (marvConsole) InputMousePressed() bool {
+	return marvlib.API.InputMousePressed()
+}
+
+func (marvConsole) InputMousePos() image.Point {
+	return marvlib.API.InputMousePos()
+}
+
+// InputChars reads the keyboard straight from ebiten, which marv runs on.
+// Typed characters only ever include printable ones, so Backspace and Enter
+// come from the keys themselves.
+func (marvConsole) InputChars() []rune {
+	chars := ebiten.AppendInputChars(nil)
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) {
+		chars = append(chars, '\b')
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		chars = append(chars, '\n')
+	}
+	return chars
+}
+
+type marvSprite struct {
+	marvtypes.Sprite
+}
+
+func (s marvSprite) Show(gfxBank int, area cartridge.MapBankArea) {
+	// areas handed out by marvMapBank are always real marv areas.
+	s.Sprite.Show(gfxBank, area.(marvtypes.MapBankArea))
+}
+
+type marvMapBank struct {
+	marvtypes.MapBank
+}
+
+func (b marvMapBank) AllocArea(size image.Point) cartridge.MapBankArea {
+	return b.MapBank.AllocArea(size)
+}
@@ -2,13 +2,13 @@ module github.com/TheMightyGit/losttheplot
 
 go 1.17
 
-replace github.com/TheMightyGit/marv => /Users/johnny/marv300
-
-require github.com/TheMightyGit/marv v0.0.0-00010101000000-000000000000
+require (
+	github.com/TheMightyGit/marv v0.3.0
+	github.com/hajimehoshi/ebiten/v2 v2.3.0-alpha.5.0.20220223184627-ea35296be77f
+)
 
 require (
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20211213063430-748e38ca8aec // indirect
-	github.com/hajimehoshi/ebiten/v2 v2.3.0-alpha.5.0.20220223184627-ea35296be77f // indirect
 	github.com/hajimehoshi/oto/v2 v2.1.0-alpha.6 // indirect
 	github.com/jezek/xgb v1.0.0 // indirect
 	github.com/jfreymuth/oggvorbis v1.0.3 // indirect